@@ -0,0 +1,217 @@
+// Copyright 2022 Security Scorecard Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package entrypoint
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ossf/scorecard-action/policies"
+	"github.com/ossf/scorecard/v4/checker"
+	"github.com/ossf/scorecard/v4/pkg"
+)
+
+// States of a check compared with the baseline.
+const (
+	// stateNewFailure is a check violating its policy, which it didn't in the baseline.
+	stateNewFailure = "new failure"
+	// stateFixed is a check violating its policy in the baseline, which it doesn't anymore.
+	stateFixed = "fixed"
+	// stateRegressed is a check scoring lower than in the baseline.
+	stateRegressed = "regressed"
+	// stateImproved is a check scoring higher than in the baseline.
+	stateImproved  = "improved"
+	stateUnchanged = "unchanged"
+)
+
+var (
+	errInvalidBaseline = errors.New("invalid baseline")
+	errRegressions     = errors.New("checks regressed since the baseline")
+)
+
+// baselineResults are the check scores of a previous run, read from its JSON results.
+type baselineResults struct {
+	commit string
+	scores map[string]int
+}
+
+// checkDelta compares a check with the baseline.
+type checkDelta struct {
+	name   string
+	before int
+	after  int
+	// inBaseline is false for the checks which didn't run in the baseline.
+	inBaseline bool
+	state      string
+}
+
+func (d checkDelta) isRegression() bool {
+	return d.state == stateNewFailure || d.state == stateRegressed
+}
+
+// readBaseline reads the check scores from the JSON results of a previous run.
+func readBaseline(path string) (*baselineResults, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading baseline file (%s): %w", path, err)
+	}
+	var results struct {
+		Repo struct {
+			Commit string `json:"commit"`
+		} `json:"repo"`
+		Checks []struct {
+			Name  string `json:"name"`
+			Score int    `json:"score"`
+		} `json:"checks"`
+	}
+	if err := json.Unmarshal(content, &results); err != nil {
+		return nil, fmt.Errorf("%w: parsing %s: %v", errInvalidBaseline, path, err)
+	}
+	if len(results.Checks) == 0 {
+		return nil, fmt.Errorf("%w: no checks in %s, expected Scorecard JSON results", errInvalidBaseline, path)
+	}
+	b := &baselineResults{commit: results.Repo.Commit, scores: map[string]int{}}
+	for _, c := range results.Checks {
+		b.scores[c.Name] = c.Score
+	}
+	return b, nil
+}
+
+// compareWithBaseline compares every check of the results with the baseline. Inconclusive scores are
+// neither regressions nor improvements, since they tell nothing of the repository.
+func compareWithBaseline(b *baselineResults, result *pkg.ScorecardResult, pol *policies.Policy,
+) []checkDelta {
+	deltas := []checkDelta{}
+	for _, c := range result.Checks {
+		d := checkDelta{name: c.Name, after: c.Score, before: checker.InconclusiveResultScore}
+		if before, ok := b.scores[c.Name]; ok {
+			d.before, d.inBaseline = before, true
+		}
+		_, failing := pol.Violation(c.Name, c.Score)
+		_, failed := pol.Violation(c.Name, d.before)
+		failed = failed && d.inBaseline
+		conclusive := d.inBaseline && d.before != checker.InconclusiveResultScore &&
+			d.after != checker.InconclusiveResultScore
+		switch {
+		case failing && !failed:
+			d.state = stateNewFailure
+		case failed && !failing && d.after != checker.InconclusiveResultScore:
+			d.state = stateFixed
+		case conclusive && d.after < d.before:
+			d.state = stateRegressed
+		case conclusive && d.after > d.before:
+			d.state = stateImproved
+		default:
+			d.state = stateUnchanged
+		}
+		deltas = append(deltas, d)
+	}
+	return deltas
+}
+
+func regressions(deltas []checkDelta) int {
+	n := 0
+	for _, d := range deltas {
+		if d.isRegression() {
+			n++
+		}
+	}
+	return n
+}
+
+// deltasAsMarkdown renders the comparison with the baseline for the job step summary.
+func deltasAsMarkdown(b *baselineResults, deltas []checkDelta) string {
+	out := "### Compared with the baseline"
+	if b.commit != "" {
+		out += fmt.Sprintf(" at `%s`", b.commit)
+	}
+	out += "\n\n"
+	out += fmt.Sprintf("%d check(s) regressed.\n\n", regressions(deltas))
+	out += "| Check | Baseline | Score | Delta | State |\n"
+	out += "| --- | --- | --- | --- | --- |\n"
+	for _, d := range deltas {
+		before, delta := "-", "-"
+		if d.inBaseline {
+			before = scoreString(float64(d.before))
+		}
+		if d.inBaseline && d.before != checker.InconclusiveResultScore && d.after != checker.InconclusiveResultScore {
+			delta = fmt.Sprintf("%+d", d.after-d.before)
+		}
+		state := d.state
+		if d.isRegression() {
+			state = ":warning: " + state
+		}
+		out += fmt.Sprintf(
+			"| %s | %s | %s | %s | %s |\n", d.name, before, scoreString(float64(d.after)), delta, state,
+		)
+	}
+	return out + "\n"
+}
+
+// sarifBaselineState returns the SARIF baselineState of the results of a check, which only has results
+// when it violates its policy: new if it didn't in the baseline, updated if its score changed since.
+func sarifBaselineState(d checkDelta) string {
+	switch {
+	case d.state == stateNewFailure:
+		return "new"
+	case d.before != d.after:
+		return "updated"
+	default:
+		return "unchanged"
+	}
+}
+
+// setSARIFBaselineState sets the baselineState of the results in the SARIF log read from r, from the
+// comparison of their checks with the baseline, and writes the log to w.
+func setSARIFBaselineState(r io.Reader, w io.Writer, deltas []checkDelta) error {
+	var log map[string]interface{}
+	if err := json.NewDecoder(r).Decode(&log); err != nil {
+		return fmt.Errorf("decoding SARIF log: %w", err)
+	}
+	byCheck := map[string]checkDelta{}
+	for _, d := range deltas {
+		byCheck[d.name] = d
+	}
+	runs, _ := log["runs"].([]interface{})
+	for _, run := range runs {
+		run, _ := run.(map[string]interface{})
+		tool, _ := run["tool"].(map[string]interface{})
+		driver, _ := tool["driver"].(map[string]interface{})
+		rules, _ := driver["rules"].([]interface{})
+		results, _ := run["results"].([]interface{})
+		for _, result := range results {
+			result, _ := result.(map[string]interface{})
+			// The rule index is a JSON number, decoded as a float64.
+			index, ok := result["ruleIndex"].(float64)
+			if !ok || int(index) < 0 || int(index) >= len(rules) {
+				continue
+			}
+			rule, _ := rules[int(index)].(map[string]interface{})
+			name, _ := rule["name"].(string)
+			if d, ok := byCheck[name]; ok {
+				result["baselineState"] = sarifBaselineState(d)
+			}
+		}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "   ")
+	if err := encoder.Encode(log); err != nil {
+		return fmt.Errorf("encoding SARIF log: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+// Copyright 2022 Security Scorecard Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package entrypoint
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ossf/scorecard-action/policies"
+	"github.com/ossf/scorecard/v4/checker"
+	docs "github.com/ossf/scorecard/v4/docs/checks"
+	sclog "github.com/ossf/scorecard/v4/log"
+	"github.com/ossf/scorecard/v4/pkg"
+)
+
+func TestCompareWithBaseline(t *testing.T) {
+	t.Parallel()
+	baselineFile := filepath.Join(t.TempDir(), "baseline.json")
+	baseline := `{
+		"repo": {"name": "github.com/owner/repo", "commit": "abc123"},
+		"checks": [
+			{"name": "Branch-Protection", "score": 10},
+			{"name": "Code-Review", "score": 3},
+			{"name": "Fuzzing", "score": 0},
+			{"name": "Maintained", "score": 10},
+			{"name": "License", "score": 9}
+		]
+	}`
+	if err := os.WriteFile(baselineFile, []byte(baseline), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	b, err := readBaseline(baselineFile)
+	if err != nil {
+		t.Fatalf("readBaseline: %v", err)
+	}
+	if b.commit != "abc123" {
+		t.Errorf("baseline commit = %q, want abc123", b.commit)
+	}
+
+	enforced := func(score int) policies.CheckPolicy {
+		return policies.CheckPolicy{Score: score, Mode: policies.ModeEnforced}
+	}
+	pol := &policies.Policy{
+		Version: 1,
+		Policies: map[string]policies.CheckPolicy{
+			"Branch-Protection": enforced(8),
+			"Code-Review":       enforced(8),
+			"Fuzzing":           enforced(8),
+			"Maintained":        enforced(8),
+			"License":           enforced(8),
+			"SAST":              enforced(8),
+		},
+	}
+	result := &pkg.ScorecardResult{
+		Checks: []checker.CheckResult{
+			{Name: "Branch-Protection", Score: 3},
+			{Name: "Code-Review", Score: 10},
+			{Name: "Fuzzing", Score: 0},
+			{Name: "Maintained", Score: 8},
+			{Name: "License", Score: checker.InconclusiveResultScore},
+			{Name: "SAST", Score: 0},
+		},
+	}
+	deltas := compareWithBaseline(b, result, pol)
+	got := map[string]string{}
+	for _, d := range deltas {
+		got[d.name] = d.state
+	}
+	want := map[string]string{
+		"Branch-Protection": stateNewFailure,
+		"Code-Review":       stateFixed,
+		"Fuzzing":           stateUnchanged,
+		"Maintained":        stateRegressed,
+		"License":           stateUnchanged,
+		"SAST":              stateNewFailure,
+	}
+	if !cmp.Equal(want, got) {
+		t.Errorf("compareWithBaseline(): -want, +got:\n%s", cmp.Diff(want, got))
+	}
+	if n := regressions(deltas); n != 3 {
+		t.Errorf("regressions() = %d, want 3", n)
+	}
+
+	markdown := deltasAsMarkdown(b, deltas)
+	for _, s := range []string{
+		"at `abc123`",
+		"3 check(s) regressed.",
+		"| Branch-Protection | 10.0 | 3.0 | -7 | :warning: new failure |",
+		"| SAST | - | 0.0 | - | :warning: new failure |",
+		"| Code-Review | 3.0 | 10.0 | +7 | fixed |",
+		"| License | 9.0 | ? | - | unchanged |",
+	} {
+		if !strings.Contains(markdown, s) {
+			t.Errorf("deltasAsMarkdown() = %q, want it to contain %q", markdown, s)
+		}
+	}
+
+	checkDocs, err := docs.Read()
+	if err != nil {
+		t.Fatalf("docs.Read: %v", err)
+	}
+	sarif := &bytes.Buffer{}
+	if err := result.AsSARIF(false, sclog.DefaultLevel, sarif, checkDocs, pol.ToScorecard()); err != nil {
+		t.Fatalf("AsSARIF: %v", err)
+	}
+	out := &bytes.Buffer{}
+	if err := setSARIFBaselineState(sarif, out, deltas); err != nil {
+		t.Fatalf("setSARIFBaselineState: %v", err)
+	}
+	var log struct {
+		Runs []struct {
+			Tool struct {
+				Driver struct {
+					Rules []struct {
+						Name string `json:"name"`
+					} `json:"rules"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Results []struct {
+				RuleIndex     int    `json:"ruleIndex"`
+				BaselineState string `json:"baselineState"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &log); err != nil {
+		t.Fatalf("unmarshalling SARIF: %v", err)
+	}
+	gotStates := map[string]string{}
+	for _, run := range log.Runs {
+		for _, r := range run.Results {
+			gotStates[run.Tool.Driver.Rules[r.RuleIndex].Name] = r.BaselineState
+		}
+	}
+	wantStates := map[string]string{
+		"Branch-Protection": "new",
+		"Fuzzing":           "unchanged",
+		"SAST":              "new",
+	}
+	if !cmp.Equal(wantStates, gotStates) {
+		t.Errorf("SARIF baseline states: -want, +got:\n%s", cmp.Diff(wantStates, gotStates))
+	}
+}
+
+func TestReadBaselineInvalid(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"sarif.json": `{"runs": []}`,
+		"text.json":  "Aggregate score: 5.0 / 10",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := readBaseline(path); err == nil {
+			t.Errorf("readBaseline(%s) succeeded, want an error", name)
+		}
+	}
+}
@@ -0,0 +1,377 @@
+// Copyright 2022 Security Scorecard Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package entrypoint
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	gogithub "github.com/google/go-github/v45/github"
+
+	"github.com/ossf/scorecard-action/github"
+	"github.com/ossf/scorecard-action/options"
+	"github.com/ossf/scorecard/v4/checker"
+	"github.com/ossf/scorecard/v4/clients"
+	"github.com/ossf/scorecard/v4/clients/githubrepo"
+	"github.com/ossf/scorecard/v4/clients/githubrepo/roundtripper"
+	docs "github.com/ossf/scorecard/v4/docs/checks"
+	sclog "github.com/ossf/scorecard/v4/log"
+	scopts "github.com/ossf/scorecard/v4/options"
+)
+
+const (
+	defaultBatchConcurrency = 4
+	defaultBatchResultsDir  = "scorecard-results"
+	batchIndexJSON          = "index.json"
+	batchIndexCSV           = "index.csv"
+	orgReposPerPage         = 100
+)
+
+var errBatch = errors.New("some repositories could not be scored")
+
+// batchEntry maps a repository to its score and results file in the batch index.
+type batchEntry struct {
+	Repo string `json:"repo"`
+	// Score is the aggregate score, or null if it is inconclusive or the repository couldn't be scored.
+	Score *float64 `json:"score"`
+	// ResultsFile is relative to the workspace.
+	ResultsFile string `json:"results_file,omitempty"`
+	Error       string `json:"error,omitempty"`
+	// writeResults writes the results file once every repository is scored, if it can't be written meanwhile.
+	writeResults func() error
+}
+
+// batchRunner scores several repositories, sharing the transport, and so the rate limit handling and
+// the connections, and the clients which don't depend on the repository between them.
+type batchRunner struct {
+	opts      *options.Options
+	checkDocs docs.Doc
+	rt        http.RoundTripper
+	shared    *scorecardClients
+	// resultsDir is relative to the workspace.
+	resultsDir string
+	// score scores a repository, it's replaced in tests.
+	score func(ctx context.Context, repo string) (*batchEntry, error)
+}
+
+// runBatch scores the repositories of the batch, writes their results files and the index of the results,
+// and summarizes them in the job step summary and outputs.
+func runBatch(ctx context.Context, opts *options.Options, checkDocs docs.Doc) error {
+	logger := sclog.NewLogger(sclog.ParseLevel(opts.ScorecardOpts.LogLevel))
+	b := &batchRunner{
+		opts:       opts,
+		checkDocs:  checkDocs,
+		rt:         roundtripper.NewTransport(ctx, logger),
+		resultsDir: opts.InputBatchResultsDir,
+	}
+	if b.resultsDir == "" {
+		b.resultsDir = defaultBatchResultsDir
+	}
+	ossFuzz, err := githubrepo.CreateOssFuzzRepoClient(ctx, logger)
+	if err != nil {
+		return fmt.Errorf("creating OSS-Fuzz repo client: %w", err)
+	}
+	defer ossFuzz.Close()
+	b.shared = &scorecardClients{
+		ossFuzz: ossFuzz,
+		cii:     clients.DefaultCIIBestPracticesClient(),
+		vulns:   clients.DefaultVulnerabilitiesClient(),
+	}
+	b.score = b.scoreRepository
+
+	repos := opts.BatchRepositories()
+	if opts.InputOrganization != "" {
+		orgRepos, err := listOrgRepositories(ctx, gogithub.NewClient(&http.Client{Transport: b.rt}), opts)
+		if err != nil {
+			return err
+		}
+		repos = append(repos, orgRepos...)
+	}
+	return b.run(ctx, repos)
+}
+
+func (b *batchRunner) run(ctx context.Context, repos []string) error {
+	if err := os.MkdirAll(filepath.Join(b.opts.GithubWorkspace, b.resultsDir), 0o755); err != nil {
+		return fmt.Errorf("creating batch results dir: %w", err)
+	}
+	repos = uniqueRepositories(repos)
+	entries := make([]batchEntry, len(repos))
+
+	var wg sync.WaitGroup
+	jobs := make(chan int)
+	workers := b.opts.InputBatchConcurrency
+	if workers <= 0 {
+		workers = defaultBatchConcurrency
+	}
+	if len(repos) < workers {
+		workers = len(repos)
+	}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				fmt.Printf("Scoring %s\n", repos[i])
+				entry, err := b.score(ctx, repos[i])
+				if err != nil {
+					entry = &batchEntry{Repo: repos[i], Error: err.Error()}
+				}
+				entries[i] = *entry
+			}
+		}()
+	}
+	for i := range repos {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	for i := range entries {
+		if entries[i].writeResults == nil {
+			continue
+		}
+		if err := entries[i].writeResults(); err != nil {
+			entries[i] = batchEntry{Repo: entries[i].Repo, Error: err.Error()}
+		}
+		entries[i].writeResults = nil
+	}
+
+	if err := b.writeIndex(entries); err != nil {
+		return err
+	}
+	if err := b.summarize(entries); err != nil {
+		return err
+	}
+	var batchErrs []error
+	for _, e := range entries {
+		if e.Error != "" {
+			batchErrs = append(batchErrs, fmt.Errorf("%s: %s", e.Repo, e.Error))
+		}
+	}
+	if len(batchErrs) > 0 {
+		return fmt.Errorf("%w: %+v", errBatch, batchErrs)
+	}
+	return nil
+}
+
+// scoreRepository runs Scorecard on a repository and writes its results file.
+func (b *batchRunner) scoreRepository(ctx context.Context, repo string) (*batchEntry, error) {
+	githubRepo, err := githubrepo.MakeGithubRepo(repo)
+	if err != nil {
+		return nil, fmt.Errorf("parsing repository: %w", err)
+	}
+	repoClient := githubrepo.CreateGithubRepoClientWithTransport(ctx, b.rt)
+	defer repoClient.Close()
+
+	// Each repository is scored at the HEAD of its default branch.
+	scOpts := *b.opts.ScorecardOpts
+	scOpts.Repo = repo
+	scOpts.Local = ""
+	scOpts.Commit = scopts.DefaultCommit
+	scOpts.EnableScorecardV6 = false
+	scOpts.Metadata = nil
+	c := *b.shared
+	c.repo = githubRepo
+	c.repoClient = repoClient
+	result, pol, err := runScorecardWithClients(ctx, &scOpts, &c)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &batchEntry{Repo: repo, ResultsFile: filepath.Join(b.resultsDir, batchResultsFile(repo, scOpts.Format))}
+	r := &resultsRenderer{result: result, checkDocs: b.checkDocs, pol: pol, scOpts: &scOpts}
+	if err := b.writeResults(entry, r, scOpts.Format); err != nil {
+		return nil, err
+	}
+	score, err := result.GetAggregateScore(b.checkDocs)
+	if err != nil {
+		return nil, fmt.Errorf("getting aggregate score: %w", err)
+	}
+	if score != checker.InconclusiveResultScore {
+		entry.Score = &score
+	}
+	return entry, nil
+}
+
+// writeResults renders the results file of a repository. The default format is rendered once every
+// repository is scored, one at a time, as it goes through os.Stdout which the workers share.
+func (b *batchRunner) writeResults(entry *batchEntry, r *resultsRenderer, format string) error {
+	write := func() error {
+		f, err := os.Create(filepath.Join(b.opts.GithubWorkspace, entry.ResultsFile))
+		if err != nil {
+			return fmt.Errorf("creating results file: %w", err)
+		}
+		defer f.Close()
+		return r.render(f, format)
+	}
+	if format == scopts.FormatDefault {
+		entry.writeResults = write
+		return nil
+	}
+	return write()
+}
+
+// batchResultsFile names the results file of a repository, e.g. owner__name.sarif.
+func batchResultsFile(repo, format string) string {
+	ext := map[string]string{
+		scopts.FormatSarif:     "sarif",
+		scopts.FormatJSON:      "json",
+		scopts.FormatRaw:       "json",
+		options.FormatMarkdown: "md",
+	}[format]
+	if ext == "" {
+		ext = "txt"
+	}
+	return strings.ReplaceAll(repo, "/", "__") + "." + ext
+}
+
+// uniqueRepositories sorts the repositories and drops the duplicates, e.g. listed and found in the organization.
+func uniqueRepositories(repos []string) []string {
+	seen := map[string]bool{}
+	unique := []string{}
+	for _, r := range repos {
+		key := strings.ToLower(r)
+		if !seen[key] {
+			seen[key] = true
+			unique = append(unique, r)
+		}
+	}
+	sort.Strings(unique)
+	return unique
+}
+
+// listOrgRepositories lists the repositories of the organization which match the filters of the options.
+func listOrgRepositories(ctx context.Context, client *gogithub.Client, opts *options.Options) ([]string, error) {
+	repos := []string{}
+	listOpts := &gogithub.RepositoryListByOrgOptions{
+		Sort:        "full_name",
+		ListOptions: gogithub.ListOptions{PerPage: orgReposPerPage},
+	}
+	for {
+		page, resp, err := client.Repositories.ListByOrg(ctx, opts.InputOrganization, listOpts)
+		if err != nil {
+			return nil, fmt.Errorf("listing the repositories of %s: %w", opts.InputOrganization, err)
+		}
+		for _, r := range page {
+			if (r.GetFork() && !opts.InputIncludeForks) || (r.GetArchived() && !opts.InputIncludeArchived) {
+				continue
+			}
+			if opts.InputRepositoryFilter != "" {
+				// The filter is validated with the options.
+				if matched, _ := path.Match(opts.InputRepositoryFilter, r.GetName()); !matched {
+					continue
+				}
+			}
+			repos = append(repos, r.GetFullName())
+		}
+		if resp.NextPage == 0 {
+			return repos, nil
+		}
+		listOpts.Page = resp.NextPage
+	}
+}
+
+// writeIndex writes the index of the results as JSON and CSV in the results dir.
+func (b *batchRunner) writeIndex(entries []batchEntry) error {
+	dir := filepath.Join(b.opts.GithubWorkspace, b.resultsDir)
+	index, err := json.MarshalIndent(struct {
+		Repositories []batchEntry `json:"repositories"`
+	}{entries}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshalling batch index: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, batchIndexJSON), append(index, '\n'), 0o644); err != nil {
+		return fmt.Errorf("writing batch index: %w", err)
+	}
+
+	f, err := os.Create(filepath.Join(dir, batchIndexCSV))
+	if err != nil {
+		return fmt.Errorf("creating batch index: %w", err)
+	}
+	defer f.Close()
+	w := csv.NewWriter(f)
+	records := [][]string{{"repo", "score", "results_file", "error"}}
+	for _, e := range entries {
+		records = append(records, []string{e.Repo, batchScore(e), e.ResultsFile, e.Error})
+	}
+	if err := w.WriteAll(records); err != nil {
+		return fmt.Errorf("writing batch index: %w", err)
+	}
+	return nil
+}
+
+// summarize writes the scores of the repositories to the job step summary and the index files to the outputs.
+func (b *batchRunner) summarize(entries []batchEntry) error {
+	if b.opts.GithubStepSummary != "" {
+		if err := appendToStepSummary(b.opts.GithubStepSummary, batchAsMarkdown(entries)); err != nil {
+			return err
+		}
+	}
+	if b.opts.GithubOutput != "" {
+		outputs := map[string]string{
+			"results_file":     filepath.Join(b.opts.GithubWorkspace, b.resultsDir, batchIndexJSON),
+			"batch_index_json": filepath.Join(b.opts.GithubWorkspace, b.resultsDir, batchIndexJSON),
+			"batch_index_csv":  filepath.Join(b.opts.GithubWorkspace, b.resultsDir, batchIndexCSV),
+		}
+		if err := github.WriteOutputs(b.opts.GithubOutput, outputs); err != nil {
+			return fmt.Errorf("writing outputs: %w", err)
+		}
+	}
+	return nil
+}
+
+// batchAsMarkdown renders the scores of the repositories for the job step summary.
+func batchAsMarkdown(entries []batchEntry) string {
+	failed := 0
+	for _, e := range entries {
+		if e.Error != "" {
+			failed++
+		}
+	}
+	out := "## [Scorecard](https://github.com/ossf/scorecard) results\n\n"
+	out += fmt.Sprintf("%d repositories scored", len(entries)-failed)
+	if failed > 0 {
+		out += fmt.Sprintf(", %d failed", failed)
+	}
+	out += ".\n\n| Repository | Score | Results |\n| --- | --- | --- |\n"
+	for _, e := range entries {
+		results := "`" + e.ResultsFile + "`"
+		if e.Error != "" {
+			results = ":x: " + markdownTableCell(e.Error)
+		}
+		score := batchScore(e)
+		if score == "" {
+			score = "?"
+		}
+		out += fmt.Sprintf("| %s | %s | %s |\n", e.Repo, score, results)
+	}
+	return out + "\n"
+}
+
+func batchScore(e batchEntry) string {
+	if e.Score == nil {
+		return ""
+	}
+	return fmt.Sprintf("%.1f", *e.Score)
+}
@@ -0,0 +1,195 @@
+// Copyright 2022 Security Scorecard Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package entrypoint
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	gogithub "github.com/google/go-github/v45/github"
+	"github.com/ossf/scorecard/v4/checker"
+	docs "github.com/ossf/scorecard/v4/docs/checks"
+	scopts "github.com/ossf/scorecard/v4/options"
+	"github.com/ossf/scorecard/v4/pkg"
+	spol "github.com/ossf/scorecard/v4/policy"
+
+	"github.com/ossf/scorecard-action/options"
+)
+
+func TestBatchRun(t *testing.T) {
+	t.Parallel()
+	workspace := t.TempDir()
+	summary := filepath.Join(workspace, "summary.md")
+	b := &batchRunner{
+		opts: &options.Options{
+			GithubWorkspace:       workspace,
+			GithubStepSummary:     summary,
+			InputBatchConcurrency: 2,
+		},
+		resultsDir: "results",
+		score: func(ctx context.Context, repo string) (*batchEntry, error) {
+			if repo == "org/broken" {
+				return nil, errors.New("repo not found")
+			}
+			score := float64(len(repo))
+			return &batchEntry{Repo: repo, Score: &score, ResultsFile: batchResultsFile(repo, "sarif")}, nil
+		},
+	}
+	err := b.run(context.Background(), []string{"org/repo-b", "org/broken", "org/a", "Org/A"})
+	if !errors.Is(err, errBatch) {
+		t.Errorf("run() error = %v, want %v", err, errBatch)
+	}
+
+	index, err := os.ReadFile(filepath.Join(workspace, "results", batchIndexCSV))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantIndex := "repo,score,results_file,error\n" +
+		"org/a,5.0,org__a.sarif,\n" +
+		"org/broken,,,repo not found\n" +
+		"org/repo-b,10.0,org__repo-b.sarif,\n"
+	if diff := cmp.Diff(wantIndex, string(index)); diff != "" {
+		t.Errorf("CSV index: -want, +got:\n%s", diff)
+	}
+	if _, err := os.Stat(filepath.Join(workspace, "results", batchIndexJSON)); err != nil {
+		t.Errorf("JSON index: %v", err)
+	}
+	content, err := os.ReadFile(summary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"2 repositories scored, 1 failed.",
+		"| org/repo-b | 10.0 | `org__repo-b.sarif` |",
+		"| org/broken | ? | :x: repo not found |",
+	} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("step summary = %q, want it to contain %q", content, want)
+		}
+	}
+}
+
+// TestBatchRunDefaultFormat checks that the text results of each repository land in its own file, as they go
+// through os.Stdout. It isn't parallel since it points os.Stdout at the results files, run it with -race.
+func TestBatchRunDefaultFormat(t *testing.T) {
+	checkDocs, err := docs.Read()
+	if err != nil {
+		t.Fatalf("docs.Read: %v", err)
+	}
+	workspace := t.TempDir()
+	repos := []string{"org/a", "org/b", "org/c", "org/d", "org/e", "org/f"}
+	b := &batchRunner{
+		opts: &options.Options{
+			GithubWorkspace:       workspace,
+			InputBatchConcurrency: 4,
+		},
+		checkDocs:  checkDocs,
+		resultsDir: "results",
+	}
+	b.score = func(ctx context.Context, repo string) (*batchEntry, error) {
+		result := &pkg.ScorecardResult{
+			Repo: pkg.RepoInfo{Name: "github.com/" + repo},
+			Checks: []checker.CheckResult{
+				{Name: "Binary-Artifacts", Score: 5, Reason: "binaries found in " + repo},
+			},
+		}
+		scOpts := scopts.New()
+		scOpts.Format = scopts.FormatDefault
+		entry := &batchEntry{Repo: repo, ResultsFile: filepath.Join(b.resultsDir, batchResultsFile(repo, scOpts.Format))}
+		r := &resultsRenderer{result: result, checkDocs: checkDocs, pol: &spol.ScorecardPolicy{}, scOpts: scOpts}
+		if err := b.writeResults(entry, r, scOpts.Format); err != nil {
+			return nil, err
+		}
+		return entry, nil
+	}
+	if err := b.run(context.Background(), repos); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	for _, repo := range repos {
+		content, err := os.ReadFile(filepath.Join(workspace, "results", batchResultsFile(repo, scopts.FormatDefault)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "binaries found in " + repo; !strings.Contains(string(content), want) {
+			t.Errorf("results of %s = %q, want it to contain %q", repo, content, want)
+		}
+		if strings.Count(string(content), "binaries found in") != 1 {
+			t.Errorf("results of %s = %q, want only its own results", repo, content)
+		}
+	}
+}
+
+func TestListOrgRepositories(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "" {
+			w.Header().Set("Link", fmt.Sprintf(`<%s%s?page=2>; rel="next"`, "http://"+r.Host, r.URL.Path))
+			fmt.Fprint(w, `[
+				{"name": "action", "full_name": "org/action"},
+				{"name": "action-fork", "full_name": "org/action-fork", "fork": true},
+				{"name": "website", "full_name": "org/website"}
+			]`)
+			return
+		}
+		fmt.Fprint(w, `[{"name": "action-old", "full_name": "org/action-old", "archived": true}]`)
+	}))
+	t.Cleanup(server.Close)
+	client := gogithub.NewClient(server.Client())
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	tests := []struct {
+		name string
+		opts *options.Options
+		want []string
+	}{
+		{
+			name: "Default",
+			opts: &options.Options{InputOrganization: "org"},
+			want: []string{"org/action", "org/website"},
+		},
+		{
+			name: "FilteredWithForksAndArchived",
+			opts: &options.Options{
+				InputOrganization:     "org",
+				InputRepositoryFilter: "action*",
+				InputIncludeForks:     true,
+				InputIncludeArchived:  true,
+			},
+			want: []string{"org/action", "org/action-fork", "org/action-old"},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := listOrgRepositories(context.Background(), client, tt.opts)
+			if err != nil {
+				t.Fatalf("listOrgRepositories: %v", err)
+			}
+			if !cmp.Equal(tt.want, got) {
+				t.Errorf("listOrgRepositories(): -want, +got:\n%s", cmp.Diff(tt.want, got))
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright 2022 Security Scorecard Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dependencydiff
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ossf/scorecard/v4/checker"
+)
+
+const (
+	// maxMatrixRows bounds the per-check matrix, which lists the riskiest changes first.
+	maxMatrixRows = 50
+	// maxReasonsLength bounds the check reasons, leaving room in the report for the dependency-diffs.
+	maxReasonsLength = 16384
+	// goodScoreThreshold is the check score from which a check is shown as passing.
+	goodScoreThreshold = 8
+)
+
+// checkMatrixAsMarkdown renders the check scores of the added and updated dependencies as a table with
+// a row per dependency and a column per check, followed by the check reasons in a collapsible section.
+func checkMatrixAsMarkdown(risks []riskyChange, checks []string) string {
+	checks = matrixColumns(risks, checks)
+	if len(risks) == 0 || len(checks) == 0 {
+		return ""
+	}
+	result := "### Check scores\n\n"
+	if len(risks) > maxMatrixRows {
+		result += fmt.Sprintf("The %d riskiest of %d added or updated dependencies:\n\n", maxMatrixRows, len(risks))
+		risks = risks[:maxMatrixRows]
+	}
+	result += "| Dependency | " + strings.Join(checks, " | ") + " |\n"
+	result += "| --- |" + strings.Repeat(" --- |", len(checks)) + "\n"
+	reasons, reasonsCut := "", false
+	for _, r := range risks {
+		row := "| `" + r.name + "` |"
+		dependencyReasons := ""
+		for _, check := range checks {
+			c := findCheck(r, check)
+			if c == nil {
+				row += " - |"
+				continue
+			}
+			row += fmt.Sprintf(" %s %s |", severityEmoji(c.Score), checkScoreCell(c.Score))
+			dependencyReasons += fmt.Sprintf(
+				"  - %s (%s): %s\n", c.Name, checkScoreCell(c.Score), strings.Join(strings.Fields(c.Reason), " "),
+			)
+		}
+		result += row + "\n"
+		if dependencyReasons == "" || reasonsCut {
+			continue
+		}
+		dependencyReasons = "- **`" + r.name + "`**\n" + dependencyReasons
+		if len(reasons)+len(dependencyReasons) > maxReasonsLength {
+			reasons += "- ...\n"
+			reasonsCut = true
+			continue
+		}
+		reasons += dependencyReasons
+	}
+	result += "\n"
+	if reasons != "" {
+		result += "<details>\n<summary>Check reasons</summary>\n\n" + reasons + "\n</details>\n\n"
+	}
+	return result
+}
+
+// matrixColumns returns the configured checks, or all the checks found in the results if none is configured.
+func matrixColumns(risks []riskyChange, checks []string) []string {
+	columns := []string{}
+	for _, check := range checks {
+		if check = strings.TrimSpace(check); check != "" {
+			columns = append(columns, check)
+		}
+	}
+	if len(columns) > 0 {
+		return columns
+	}
+	found := map[string]bool{}
+	for _, r := range risks {
+		if r.result == nil {
+			continue
+		}
+		for _, c := range r.result.Checks {
+			if !found[c.Name] {
+				found[c.Name] = true
+				columns = append(columns, c.Name)
+			}
+		}
+	}
+	sort.Strings(columns)
+	return columns
+}
+
+func findCheck(r riskyChange, check string) *checker.CheckResult {
+	if r.result == nil {
+		return nil
+	}
+	for i := range r.result.Checks {
+		if strings.EqualFold(r.result.Checks[i].Name, check) {
+			return &r.result.Checks[i]
+		}
+	}
+	return nil
+}
+
+func severityEmoji(score int) string {
+	switch {
+	case score == checker.InconclusiveResultScore:
+		return ":grey_question:"
+	case score < lowScoreThreshold:
+		return ":red_circle:"
+	case score < goodScoreThreshold:
+		return ":yellow_circle:"
+	default:
+		return ":green_circle:"
+	}
+}
+
+func checkScoreCell(score int) string {
+	if score == checker.InconclusiveResultScore {
+		return "?"
+	}
+	return fmt.Sprint(score)
+}
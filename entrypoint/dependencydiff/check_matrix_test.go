@@ -0,0 +1,82 @@
+// Copyright 2022 Security Scorecard Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//nolint
+package dependencydiff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ossf/scorecard/v4/pkg"
+)
+
+func Test_checkMatrixAsMarkdown(t *testing.T) {
+	t.Parallel()
+	low := testDependency("low", pkg.Added, map[string]int{"Code-Review": 2, "Maintained": -1})
+	low.ScorecardResultWithError.ScorecardResult.Checks[0].Reason = "found 1/5 approved\nchangesets"
+	high := testDependency("high", pkg.Added, map[string]int{"Code-Review": 10})
+	unscored := testDependency("unscored", pkg.Added, nil)
+	risks := []riskyChange{
+		newRiskyChange(&low, 2, nil, nil),
+		newRiskyChange(&high, 10, nil, nil),
+		newRiskyChange(&unscored, negInf, nil, nil),
+	}
+	tests := []struct {
+		name     string
+		checks   []string
+		wantRows []string
+	}{
+		{
+			name:   "ConfiguredChecks",
+			checks: []string{"Code-Review"},
+			wantRows: []string{
+				"| Dependency | Code-Review |",
+				"| `low` | :red_circle: 2 |",
+				"| `high` | :green_circle: 10 |",
+				"| `unscored` | - |",
+			},
+		},
+		{
+			name:   "ChecksFromResults",
+			checks: []string{""},
+			wantRows: []string{
+				"| Dependency | Code-Review | Maintained |",
+				"| `low` | :red_circle: 2 | :grey_question: ? |",
+				"| `high` | :green_circle: 10 | - |",
+				"| `unscored` | - | - |",
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := checkMatrixAsMarkdown(risks, tt.checks)
+			rows := []string{}
+			for _, line := range strings.Split(got, "\n") {
+				if strings.HasPrefix(line, "| ") && !strings.HasPrefix(line, "| ---") {
+					rows = append(rows, line)
+				}
+			}
+			if !cmp.Equal(tt.wantRows, rows) {
+				t.Errorf("rows: -want, +got:\n%s", cmp.Diff(tt.wantRows, rows))
+			}
+			if !strings.Contains(got, "  - Code-Review (2): found 1/5 approved changesets\n") {
+				t.Errorf("checkMatrixAsMarkdown() = %q, want the check reasons on a single line", got)
+			}
+		})
+	}
+}
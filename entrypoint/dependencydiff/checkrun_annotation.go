@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"os"
 	"sort"
+	"unicode/utf8"
 
 	"github.com/google/go-github/v45/github"
 	"github.com/ossf/scorecard-action/options"
@@ -27,55 +28,146 @@ import (
 )
 
 const (
-	fakeStartLine = 1
-	fakeEndLine   = 2
-	msgNoResults  = "No Scorecard check results are available for this dependency, or this is a removed one."
+	msgNoResults = "No Scorecard check results are available for this dependency, or this is a removed one."
+
+	checkRunName  = "Scorecard Action Dependency-diff"
+	checkRunTitle = "Scorecard Action Dependency-diff check results"
+
+	// GitHub limits on the check run requests and outputs.
+	annotationsPerRequest = 50
+	maxOutputLength       = 65535
+	truncationNotice      = "\n\n> :scissors: Truncated, the full output has %d characters."
+	droppedNotice         = "\n\n> :warning: Some annotations could not be added to this check run: %v"
 )
 
 func visualizeToCheckRun(ctx context.Context, ghClient *github.Client,
-	owner, repo string,
-	deps []pkg.DependencyCheckResult,
+	owner, repo, headSHA string,
+	deps []pkg.DependencyCheckResult, decisions policyDecisions, violations []gateViolation,
 ) error {
-	headSHA := os.Getenv(options.EnvInputPullRequestHeadSHA)
 	if headSHA == "" {
 		return fmt.Errorf("%w: head ref", errEmpty)
 	}
-	annotations, err := createAnnotations(deps)
+	annotations, err := createAnnotations(deps, decisions, os.Getenv(options.EnvGithubWorkspace))
 	if err != nil {
 		return fmt.Errorf("error creating annotations: %w", err)
 	}
-	output := github.CheckRunOutput{
-		Title: asPointerStr("Scorecard Action Dependency-diff check results"),
-		Summary: asPointerStr(
-			fmt.Sprintf(
-				":sparkles: **%d** dependency-diffs (changes) found, **%d** annotations created.",
-				len(deps), len(annotations),
-			),
-		),
-		Annotations: annotations,
+	summary := fmt.Sprintf(
+		":sparkles: **%d** dependency-diffs (changes) found, **%d** annotations created.",
+		len(deps), len(annotations),
+	)
+	conclusion := "neutral"
+	if len(violations) > 0 {
+		// Fail the check run if any added or updated dependency is below the configured thresholds.
+		conclusion = "failure"
+		summary += "\n\n" + violationsAsMarkdown(violations)
+	}
+	return writeCheckRun(ctx, ghClient, owner, repo, headSHA, conclusion, summary, annotations)
+}
+
+// writeCheckRun creates the check run with the first batch of annotations and adds the remaining ones
+// with batched updates, since GitHub accepts at most 50 annotations per request.
+func writeCheckRun(ctx context.Context, ghClient *github.Client, owner, repo, headSHA, conclusion, summary string,
+	annotations []*github.CheckRunAnnotation,
+) error {
+	batches := [][]*github.CheckRunAnnotation{}
+	for len(annotations) > annotationsPerRequest {
+		batches = append(batches, annotations[:annotationsPerRequest])
+		annotations = annotations[annotationsPerRequest:]
 	}
+	batches = append(batches, annotations)
+	summary = truncateOutput(summary)
+
 	opts := github.CreateCheckRunOptions{
-		Name:    "Scorecard Action Dependency-diff",
+		Name:    checkRunName,
 		HeadSHA: headSHA,
 		// DetailsURL should be the integrator's site that has the full details of the check.
 		// TODO (#issue number): Leave this as nil for now to make it explicit. This might be a
 		// corresponding scorecard check page for a specific package once we have the security-scorecard.dev website.
 		// https://github.com/google/go-github/blob/master/github/checks.go#L142
 		DetailsURL: asPointerStr("https://deps.dev/"),
-		Status:     asPointerStr("completed"),
-		Conclusion: asPointerStr("neutral"),
-		Output:     &output,
+		Output: &github.CheckRunOutput{
+			Title:       asPointerStr(checkRunTitle),
+			Summary:     asPointerStr(summary),
+			Annotations: batches[0],
+		},
+	}
+	if len(batches) == 1 {
+		opts.Status = asPointerStr("completed")
+		opts.Conclusion = asPointerStr(conclusion)
+	} else {
+		// Keep the check run in progress until the last batch of annotations is added.
+		opts.Status = asPointerStr("in_progress")
 	}
-	_, _, err = ghClient.Checks.CreateCheckRun(
+	checkRun, _, err := ghClient.Checks.CreateCheckRun(
 		ctx, owner, repo, opts,
 	)
 	if err != nil {
 		return fmt.Errorf("error creating the check run: %w", err)
 	}
+	for i, batch := range batches[1:] {
+		updateOpts := github.UpdateCheckRunOptions{
+			Name: checkRunName,
+			Output: &github.CheckRunOutput{
+				Title:       asPointerStr(checkRunTitle),
+				Summary:     asPointerStr(summary),
+				Annotations: batch,
+			},
+		}
+		if i == len(batches)-2 {
+			updateOpts.Status = asPointerStr("completed")
+			updateOpts.Conclusion = asPointerStr(conclusion)
+		}
+		_, _, err = ghClient.Checks.UpdateCheckRun(
+			ctx, owner, repo, checkRun.GetID(), updateOpts,
+		)
+		if err != nil {
+			completeCheckRun(ctx, ghClient, owner, repo, checkRun.GetID(), conclusion, summary, err)
+			return fmt.Errorf("error adding annotations to the check run: %w", err)
+		}
+	}
 	return nil
 }
 
-func createAnnotations(deps []pkg.DependencyCheckResult) ([]*github.CheckRunAnnotation, error) {
+// completeCheckRun completes a check run whose annotations could not all be added, so that it doesn't stay
+// in progress forever. It is best-effort, as the error adding the annotations is returned anyway.
+func completeCheckRun(ctx context.Context, ghClient *github.Client, owner, repo string, id int64,
+	conclusion, summary string, annotationsErr error,
+) {
+	summary = truncateOutput(summary + fmt.Sprintf(droppedNotice, annotationsErr))
+	_, _, err := ghClient.Checks.UpdateCheckRun(
+		ctx, owner, repo, id,
+		github.UpdateCheckRunOptions{
+			Name:       checkRunName,
+			Status:     asPointerStr("completed"),
+			Conclusion: asPointerStr(conclusion),
+			Output: &github.CheckRunOutput{
+				Title:   asPointerStr(checkRunTitle),
+				Summary: asPointerStr(summary),
+			},
+		},
+	)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error completing the check run: %v\n", err)
+	}
+}
+
+// truncateOutput truncates a check run output field to the size GitHub accepts, with a notice.
+func truncateOutput(s string) string {
+	if len(s) <= maxOutputLength {
+		return s
+	}
+	notice := fmt.Sprintf(truncationNotice, utf8.RuneCountInString(s))
+	cut := maxOutputLength - len(notice)
+	// Do not cut a multi-byte character in half.
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + notice
+}
+
+func createAnnotations(deps []pkg.DependencyCheckResult, decisions policyDecisions,
+	workspace string,
+) ([]*github.CheckRunAnnotation, error) {
 	annotations := []*github.CheckRunAnnotation{}
 	// Do sorting for dependencies by their aggregate scores in descending order.
 	added, removed := dependencySliceToMaps(deps)
@@ -87,6 +179,10 @@ func createAnnotations(deps []pkg.DependencyCheckResult) ([]*github.CheckRunAnno
 	if err != nil {
 		return nil, err
 	}
+	updates, err := findUpdates(added, removed)
+	if err != nil {
+		return nil, err
+	}
 	sort.SliceStable(
 		addedSortKeys,
 		func(i, j int) bool { return addedSortKeys[i].aggregateScore > addedSortKeys[j].aggregateScore },
@@ -100,14 +196,29 @@ func createAnnotations(deps []pkg.DependencyCheckResult) ([]*github.CheckRunAnno
 			return nil, fmt.Errorf("%w: map entry", errInvalid)
 		}
 		dName := key.dependencyName
+		line := findDependencyLine(
+			workspace, valueOrEmpty(added[dName].ManifestPath), dName, valueOrEmpty(added[dName].Version),
+		)
+		changeType, note := added[dName].ChangeType, decisions.policyMessage(dName)
+		u, updated := updates[dName]
+		if updated {
+			updatedType := pkg.Updated
+			changeType = &updatedType
+			note += updateMessage(&u)
+		}
 		results, err := annotationHelper(
-			dName, added[dName].ManifestPath, added[dName].Version,
-			key.aggregateScore, added[dName].ChangeType,
+			dName, added[dName].ManifestPath, added[dName].Version, line, note,
+			key.aggregateScore, changeType,
 			added[dName].ScorecardResultWithError.ScorecardResult,
 		)
 		if err != nil {
 			return nil, err
 		}
+		if updated && len(u.warnings()) > 0 {
+			for _, a := range results {
+				a.AnnotationLevel = asPointerStr("warning")
+			}
+		}
 		annotations = append(
 			annotations, results...,
 		)
@@ -117,8 +228,15 @@ func createAnnotations(deps []pkg.DependencyCheckResult) ([]*github.CheckRunAnno
 			return nil, fmt.Errorf("%w: map entry", errInvalid)
 		}
 		dName := key.dependencyName
+		if _, ok := updates[dName]; ok {
+			// The old versions of updated dependencies are annotated along with the new ones.
+			continue
+		}
+		line := findDependencyLine(
+			workspace, valueOrEmpty(removed[dName].ManifestPath), dName, valueOrEmpty(removed[dName].Version),
+		)
 		results, err := annotationHelper(
-			dName, removed[dName].ManifestPath, removed[dName].Version,
+			dName, removed[dName].ManifestPath, removed[dName].Version, line, decisions.policyMessage(dName),
 			key.aggregateScore, removed[dName].ChangeType,
 			removed[dName].ScorecardResultWithError.ScorecardResult,
 		)
@@ -132,7 +250,7 @@ func createAnnotations(deps []pkg.DependencyCheckResult) ([]*github.CheckRunAnno
 	return annotations, nil
 }
 
-func annotationHelper(name string, manifest, version *string, aggregate float64,
+func annotationHelper(name string, manifest, version *string, line int, policyNote string, aggregate float64,
 	changeType *pkg.ChangeType, scorecardResult *pkg.ScorecardResult,
 ) ([]*github.CheckRunAnnotation, error) {
 	annotations := []*github.CheckRunAnnotation{}
@@ -144,19 +262,17 @@ func annotationHelper(name string, manifest, version *string, aggregate float64,
 		for _, c := range scorecardResult.Checks {
 			a := github.CheckRunAnnotation{
 				// No need for nil pointer checking since a.Path is also a pointer type.
-				Path: manifest,
-				// TODO (#issue number): use the actual start lines and end lines in manifest/lock file if a future
-				// data source has such fields.
-				StartLine:       asPointerInt(fakeStartLine), /* Fake the start line since we don't have the data for now. */
-				EndLine:         asPointerInt(fakeEndLine),   /* Fake the end line since we don't have the data for now. */
+				Path:            manifest,
+				StartLine:       asPointerInt(line),
+				EndLine:         asPointerInt(line),
 				AnnotationLevel: asPointerStr("notice"),
 				Message: asPointerStr(
 					fmt.Sprintf(
-						"Check: %s\nScore: %.1f\nReason: %s",
-						c.Name, float64(c.Score), c.Reason,
+						"Check: %s\nScore: %.1f\nReason: %s%s",
+						c.Name, float64(c.Score), c.Reason, policyNote,
 					),
 				),
-				RawDetails: asPointerStr(fmt.Sprint(*scorecardResult)),
+				RawDetails: asPointerStr(truncateOutput(fmt.Sprint(*scorecardResult))),
 			}
 			if changeType != nil && version != nil {
 				a.Title = asPointerStr(fmt.Sprintf(
@@ -173,9 +289,8 @@ func annotationHelper(name string, manifest, version *string, aggregate float64,
 					),
 				)
 			}
-			// Should we do this?
-			// My thought is to make this a warning if the score of a check is lower than a certain value.
-			if c.Score < 6.0 {
+			// Make this a warning if the score of a check is lower than a certain value.
+			if c.Score < lowScoreThreshold {
 				a.AnnotationLevel = asPointerStr("warning")
 			} else {
 				a.AnnotationLevel = asPointerStr("notice")
@@ -186,9 +301,9 @@ func annotationHelper(name string, manifest, version *string, aggregate float64,
 		// Create exactly one annotation for those having a null scorecard check field.
 		a := github.CheckRunAnnotation{
 			Path:      manifest,
-			StartLine: asPointerInt(fakeStartLine),
-			EndLine:   asPointerInt(fakeEndLine),
-			Message:   asPointerStr(msgNoResults),
+			StartLine: asPointerInt(line),
+			EndLine:   asPointerInt(line),
+			Message:   asPointerStr(msgNoResults + policyNote),
 		}
 		if *changeType == pkg.Removed {
 			a.AnnotationLevel = asPointerStr("notice")
@@ -208,6 +323,26 @@ func annotationHelper(name string, manifest, version *string, aggregate float64,
 	return annotations, nil
 }
 
+// updateMessage describes the version and score changes of an updated dependency for the annotations.
+func updateMessage(u *dependencyUpdate) string {
+	if u.oldResult == nil {
+		return ""
+	}
+	result := fmt.Sprintf("\nUpdated from: %s", valueOrEmpty(u.oldResult.Version))
+	if u.oldAggregate != checker.InconclusiveResultScore && u.newAggregate != checker.InconclusiveResultScore {
+		result += fmt.Sprintf("\nAggregate score: %.1f → %.1f", u.oldAggregate, u.newAggregate)
+	}
+	for _, d := range u.deltas {
+		if d.oldScore != d.newScore {
+			result += fmt.Sprintf("\n%s: %d → %d", d.name, d.oldScore, d.newScore)
+		}
+	}
+	for _, w := range u.warnings() {
+		result += "\nWarning: " + w
+	}
+	return result
+}
+
 func asPointerStr(s string) *string {
 	return &s
 }
@@ -215,3 +350,10 @@ func asPointerStr(s string) *string {
 func asPointerInt(i int) *int {
 	return &i
 }
+
+func valueOrEmpty(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
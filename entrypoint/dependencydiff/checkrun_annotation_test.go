@@ -0,0 +1,111 @@
+// Copyright 2022 Security Scorecard Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//nolint
+package dependencydiff
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v45/github"
+)
+
+func Test_writeCheckRun(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name            string
+		annotations     int
+		failAnnotations bool
+		want            []checkRunRequest
+		wantErr         bool
+	}{
+		{
+			name:        "SingleRequest",
+			annotations: 50,
+			want: []checkRunRequest{
+				{method: http.MethodPost, status: "completed", conclusion: "failure", annotations: 50},
+			},
+		},
+		{
+			name:        "BatchedUpdates",
+			annotations: 120,
+			want: []checkRunRequest{
+				{method: http.MethodPost, status: "in_progress", annotations: 50},
+				{method: http.MethodPatch, annotations: 50},
+				{method: http.MethodPatch, status: "completed", conclusion: "failure", annotations: 20},
+			},
+		},
+		{
+			name:            "FailedUpdateCompletes",
+			annotations:     120,
+			failAnnotations: true,
+			want: []checkRunRequest{
+				{method: http.MethodPost, status: "in_progress", annotations: 50},
+				{method: http.MethodPatch, annotations: 50},
+				{method: http.MethodPatch, status: "completed", conclusion: "failure"},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			annotations := []*github.CheckRunAnnotation{}
+			for i := 0; i < tt.annotations; i++ {
+				annotations = append(annotations, &github.CheckRunAnnotation{
+					Path:            github.String("go.mod"),
+					StartLine:       github.Int(i + 1),
+					EndLine:         github.Int(i + 1),
+					AnnotationLevel: github.String("notice"),
+					Message:         github.String("message"),
+				})
+			}
+			f := &fakeGitHub{failAnnotations: tt.failAnnotations}
+			ghClient := newTestClient(t, f)
+			err := writeCheckRun(
+				context.Background(), ghClient, testOwner, testRepo, "sha", "failure", "summary", annotations,
+			)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("writeCheckRun() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !cmp.Equal(tt.want, f.checkRuns, cmp.AllowUnexported(checkRunRequest{})) {
+				t.Errorf("-want, +got:\n%s", cmp.Diff(tt.want, f.checkRuns, cmp.AllowUnexported(checkRunRequest{})))
+			}
+		})
+	}
+}
+
+func Test_truncateOutput(t *testing.T) {
+	t.Parallel()
+	if got := truncateOutput("short"); got != "short" {
+		t.Errorf("truncateOutput() = %q, want it unchanged", got)
+	}
+	long := strings.Repeat("✨", maxOutputLength)
+	got := truncateOutput(long)
+	if len(got) > maxOutputLength {
+		t.Errorf("truncateOutput() has %d bytes, want at most %d", len(got), maxOutputLength)
+	}
+	if !utf8.ValidString(got) {
+		t.Error("truncateOutput() cut a character in half")
+	}
+	if !strings.Contains(got, "Truncated") {
+		t.Error("truncateOutput() has no truncation notice")
+	}
+}
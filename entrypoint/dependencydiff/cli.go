@@ -0,0 +1,159 @@
+// Copyright 2022 Security Scorecard Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dependencydiff
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// CommandName is the name of the dependency-diff CLI command.
+const CommandName = "depdiff"
+
+// Output formats of the dependency-diff CLI.
+const (
+	formatMarkdown = "markdown"
+	formatJSON     = "json"
+	formatSARIF    = "sarif"
+)
+
+// cliOptions are the flags of the dependency-diff CLI.
+type cliOptions struct {
+	repo              string
+	base              string
+	head              string
+	checks            string
+	changeTypes       string
+	format            string
+	outputFile        string
+	workspace         string
+	minAggregateScore string
+	minCheckScores    string
+	policyFile        string
+	depsDevBaseURL    string
+	depsDevCacheDir   string
+}
+
+// NewCommand creates the dependency-diff CLI command, which runs the same pipeline as the action
+// and prints the report instead of writing to a pull request. It reads the GitHub token from GITHUB_AUTH_TOKEN.
+func NewCommand() *cobra.Command {
+	o := &cliOptions{}
+	cmd := &cobra.Command{
+		Use:   CommandName,
+		Short: "Preview the Scorecard dependency-diff report between two references of a repository",
+		Example: "  GITHUB_AUTH_TOKEN=<token> scorecard-action depdiff " +
+			"--repo ossf/scorecard-action --base main --head feature --format markdown",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.run(cmd)
+		},
+	}
+	flags := cmd.Flags()
+	flags.StringVar(&o.repo, "repo", "", "repository to diff, as owner/name")
+	flags.StringVar(&o.base, "base", "", "base reference, e.g. the branch a pull request merges into")
+	flags.StringVar(&o.head, "head", "", "head reference, e.g. the branch of a pull request")
+	flags.StringVar(&o.checks, "checks", "Maintained,Security-Policy,License,Code-Review,SAST",
+		"comma-separated Scorecard checks to run on the dependencies")
+	flags.StringVar(&o.changeTypes, "change-types", "added", "comma-separated change types [added, updated, removed]")
+	flags.StringVar(&o.format, "format", formatMarkdown, "output format [markdown, json, sarif]")
+	flags.StringVar(&o.outputFile, "output-file", "", "file to write the report to, defaults to stdout")
+	flags.StringVar(&o.workspace, "workspace", ".", "checkout of the repository, used for the policy file and manifests")
+	flags.StringVar(&o.minAggregateScore, "min-aggregate-score", "", "minimum aggregate score of the dependencies")
+	flags.StringVar(&o.minCheckScores, "min-check-scores", "",
+		"comma-separated minimum check scores of the dependencies, e.g. Maintained>=5")
+	flags.StringVar(&o.policyFile, "policy-file", "",
+		"dependency policy file, defaults to "+defaultDependencyPolicyFile+" in the workspace if it exists")
+	flags.StringVar(&o.depsDevBaseURL, "deps-dev-base-url", "", "base URL of the deps.dev instance to query")
+	flags.StringVar(&o.depsDevCacheDir, "deps-dev-cache-dir", "", "directory to cache the deps.dev lookups")
+	for _, f := range []string{"repo", "base", "head"} {
+		// MarkFlagRequired only fails for unknown flags.
+		_ = cmd.MarkFlagRequired(f) //nolint:errcheck
+	}
+	return cmd
+}
+
+func (o *cliOptions) run(cmd *cobra.Command) error {
+	if len(strings.Split(o.repo, "/")) != 2 {
+		return fmt.Errorf("%w: repo %q, want owner/name", errInvalid, o.repo)
+	}
+	switch o.format {
+	case formatMarkdown, formatJSON, formatSARIF:
+	default:
+		return fmt.Errorf("%w: format %q", errInvalid, o.format)
+	}
+	gate, err := parseScoreGate(o.minAggregateScore, o.minCheckScores)
+	if err != nil {
+		return err
+	}
+	depPolicy, err := readDependencyPolicy(o.workspace, o.policyFile)
+	if err != nil {
+		return err
+	}
+	results, err := runDependencyDiff(cmd.Context(), &runConfig{
+		repoURI:     o.repo,
+		base:        o.base,
+		head:        o.head,
+		checks:      strings.Split(o.checks, ","),
+		changeTypes: strings.Split(o.changeTypes, ","),
+		gate:        gate,
+		policy:      depPolicy,
+	})
+	if err != nil {
+		return err
+	}
+
+	var out io.Writer = cmd.OutOrStdout()
+	if o.outputFile != "" {
+		f, err := os.Create(o.outputFile)
+		if err != nil {
+			return fmt.Errorf("error creating output file (%s): %w", o.outputFile, err)
+		}
+		defer f.Close()
+		out = f
+	}
+	switch o.format {
+	case formatJSON:
+		err = dependencydiffResultsAsJSON(results.deps, o.base, o.head, out)
+	case formatSARIF:
+		err = dependencydiffResultsAsSARIF(results.deps, gate, o.head, o.workspace, out)
+	default:
+		var report *string
+		report, err = dependencydiffResultsAsMarkdown(cmd.Context(), results.deps, &markdownOptions{
+			decisions:      results.decisions,
+			depsDev:        NewDepsDevClient(DepsDevOptions{BaseURL: o.depsDevBaseURL, CacheDir: o.depsDevCacheDir}),
+			depsDevBaseURL: o.depsDevBaseURL,
+			base:           o.base,
+			head:           o.head,
+			checks:         strings.Split(o.checks, ","),
+		})
+		if err == nil {
+			_, err = io.WriteString(out, *report+"\n")
+		}
+		if err == nil && len(results.violations) > 0 {
+			_, err = io.WriteString(out, "\n"+violationsAsMarkdown(results.violations))
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("error writing the %s report: %w", o.format, err)
+	}
+	if len(results.violations) > 0 {
+		return fmt.Errorf("%w: %d violation(s)", errScoreThreshold, len(results.violations))
+	}
+	return nil
+}
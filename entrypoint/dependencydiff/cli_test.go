@@ -0,0 +1,67 @@
+// Copyright 2022 Security Scorecard Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//nolint
+package dependencydiff
+
+import (
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestNewCommand_invalidFlags(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr error
+	}{
+		{name: "MissingHead", args: []string{"--repo", "owner/repo", "--base", "main"}},
+		{
+			name:    "InvalidRepo",
+			args:    []string{"--repo", "repo", "--base", "main", "--head", "feature"},
+			wantErr: errInvalid,
+		},
+		{
+			name: "InvalidFormat",
+			args: []string{"--repo", "owner/repo", "--base", "main", "--head", "feature", "--format", "html"},
+			wantErr: errInvalid,
+		},
+		{
+			name: "InvalidGate",
+			args: []string{
+				"--repo", "owner/repo", "--base", "main", "--head", "feature", "--min-check-scores", "Maintained",
+			},
+			wantErr: errInvalid,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			cmd := NewCommand()
+			cmd.SetArgs(tt.args)
+			cmd.SetOut(io.Discard)
+			cmd.SetErr(io.Discard)
+			err := cmd.Execute()
+			if err == nil {
+				t.Fatal("Execute() succeeded, want an error")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Execute() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -18,14 +18,12 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"net/http"
 	"net/url"
-	"os"
 	"sort"
-	"strconv"
 	"strings"
 
 	"github.com/google/go-github/v45/github"
-	"github.com/ossf/scorecard-action/options"
 	"github.com/ossf/scorecard/v4/checker"
 
 	"github.com/ossf/scorecard/v4/pkg"
@@ -34,6 +32,16 @@ import (
 const (
 	// negInif is "negative infinity" used for dependencydiff results ranking.
 	negInf float64 = -math.MaxFloat64
+
+	// reportMarker is a hidden marker used to find the reports posted by previous runs.
+	reportMarker    = "<!-- scorecard-action:dependency-diff-report -->"
+	commentsPerPage = 100
+	// githubActionsLogin posts the comments with the GITHUB_TOKEN of a workflow, which can't look itself up.
+	githubActionsLogin = "github-actions[bot]"
+
+	// Comment modes.
+	commentModeUpdate   = "update"
+	commentModeMinimize = "minimize"
 )
 
 type scoreAndDependencyName struct {
@@ -41,30 +49,65 @@ type scoreAndDependencyName struct {
 	aggregateScore float64
 }
 
-func writeToComment(ctx context.Context, ghClient *github.Client, owner, repo string, report *string) error {
-	ref := os.Getenv(options.EnvGithubRef)
-	splitted := strings.Split(ref, "/")
-	// https://docs.github.com/en/actions/using-workflows/events-that-trigger-workflows#pull_request
-	// For a pull request-triggred workflow, the env GITHUB_REF has the following format:
-	// refs/pull/:prNumber/merge.
-	if len(splitted) != 4 {
-		return fmt.Errorf("%w: github ref", errEmpty)
+func writeToComment(ctx context.Context, ghClient *github.Client, owner, repo string,
+	prNumber int, report *string, mode string,
+) error {
+	// A previous run of the Action may have already left a report in this pull request. Reports carry a hidden
+	// marker so that we can find them again, since the comment IDs are not kept between workflow runs.
+	// Only the reports we posted count, as anyone can quote the marker.
+	author, err := reportAuthor(ctx, ghClient)
+	if err != nil {
+		return err
 	}
-	prNumber, err := strconv.Atoi(splitted[2])
+	previous, err := listReportComments(ctx, ghClient, owner, repo, prNumber, author)
 	if err != nil {
-		return fmt.Errorf("error converting str pr number to int: %w", err)
+		return err
 	}
+	switch mode {
+	case commentModeUpdate:
+		if len(previous) == 0 {
+			return createComment(ctx, ghClient, owner, repo, prNumber, report)
+		}
+		// Update the most recent report in place. Older duplicates, if any, are left untouched.
+		latest := previous[len(previous)-1]
+		_, _, err = ghClient.Issues.EditComment(
+			ctx, owner, repo, latest.GetID(),
+			&github.IssueComment{
+				Body: report,
+			},
+		)
+		if err != nil {
+			return fmt.Errorf("error editing comment: %w", err)
+		}
+		return nil
+	case commentModeMinimize:
+		// Post a fresh report and collapse the superseded ones so the conversation stays readable.
+		if err := createComment(ctx, ghClient, owner, repo, prNumber, report); err != nil {
+			return err
+		}
+		// The reports hidden by the previous runs are left alone, so that a run only hides the last one.
+		hidden, err := minimizedComments(ctx, ghClient, previous)
+		if err != nil {
+			return err
+		}
+		for _, c := range previous {
+			if hidden[c.GetNodeID()] {
+				continue
+			}
+			if err := minimizeComment(ctx, ghClient, c.GetNodeID()); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("%w: comment mode %s", errInvalid, mode)
+	}
+}
 
-	// The current solution could result in a pull request full of our reports and drown out other comments.
-	// Create a new issue comment in the pull request and print the report there.
-
-	// A better solution is to check if there is an existing comment and update it if there is. However, the GitHub API
-	// only supports comment lookup by commentID, whose context will be lost if this runs again in the Action.
-	// GitHub API docs: https://docs.github.com/en/rest/issues/comments#get-an-issue-comment
-	// The go-github API: https://github.com/google/go-github/blob/master/github/issues_comments.go#L87
-
-	// TODO (#issue number): Try to update an existing comment first, create a new one iff. there is not.
-	_, _, err = ghClient.Issues.CreateComment(
+func createComment(ctx context.Context, ghClient *github.Client, owner, repo string,
+	prNumber int, report *string,
+) error {
+	_, _, err := ghClient.Issues.CreateComment(
 		ctx, owner, repo, prNumber,
 		&github.IssueComment{
 			Body: report,
@@ -76,10 +119,152 @@ func writeToComment(ctx context.Context, ghClient *github.Client, owner, repo st
 	return nil
 }
 
-// dependencydiffResultsAsMarkdown exports the dependencydiff results as markdown.
-func dependencydiffResultsAsMarkdown(depdiffResults []pkg.DependencyCheckResult,
-	base, head string) (*string, error) {
+// reportAuthor returns the login the reports are posted as. The GITHUB_TOKEN of a workflow can't read the
+// authenticated user, so the reports are then posted by GitHub Actions.
+func reportAuthor(ctx context.Context, ghClient *github.Client) (string, error) {
+	user, resp, err := ghClient.Users.Get(ctx, "")
+	if err != nil {
+		if resp != nil && (resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusNotFound) {
+			return githubActionsLogin, nil
+		}
+		return "", fmt.Errorf("error getting the authenticated user: %w", err)
+	}
+	if user.GetLogin() == "" {
+		return githubActionsLogin, nil
+	}
+	return user.GetLogin(), nil
+}
+
+// listReportComments returns the dependency-diff reports posted by the author in the pull request, oldest first.
+func listReportComments(ctx context.Context, ghClient *github.Client, owner, repo string,
+	prNumber int, author string,
+) ([]*github.IssueComment, error) {
+	reports := []*github.IssueComment{}
+	opts := &github.IssueListCommentsOptions{
+		Sort:        asPointerStr("created"),
+		Direction:   asPointerStr("asc"),
+		ListOptions: github.ListOptions{PerPage: commentsPerPage},
+	}
+	for {
+		comments, resp, err := ghClient.Issues.ListComments(ctx, owner, repo, prNumber, opts)
+		if err != nil {
+			return nil, fmt.Errorf("error listing comments: %w", err)
+		}
+		for _, c := range comments {
+			if c.GetUser().GetLogin() == author && strings.Contains(c.GetBody(), reportMarker) {
+				reports = append(reports, c)
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return reports, nil
+}
 
+// minimizedComments returns the node IDs of the comments which are already hidden. The REST API doesn't tell,
+// so we go through GraphQL.
+// GitHub API docs: https://docs.github.com/en/graphql/reference/objects#issuecomment
+func minimizedComments(ctx context.Context, ghClient *github.Client,
+	comments []*github.IssueComment,
+) (map[string]bool, error) {
+	hidden := map[string]bool{}
+	ids := []string{}
+	for _, c := range comments {
+		if c.GetNodeID() != "" {
+			ids = append(ids, c.GetNodeID())
+		}
+	}
+	// A query takes at most 100 node IDs.
+	for len(ids) > 0 {
+		n := len(ids)
+		if n > commentsPerPage {
+			n = commentsPerPage
+		}
+		body := map[string]interface{}{
+			"query":     "query($ids: [ID!]!) { nodes(ids: $ids) { ... on IssueComment { id isMinimized } } }",
+			"variables": map[string]interface{}{"ids": ids[:n]},
+		}
+		req, err := ghClient.NewRequest(http.MethodPost, "graphql", body)
+		if err != nil {
+			return nil, fmt.Errorf("error creating graphql request: %w", err)
+		}
+		resp := struct {
+			Data struct {
+				Nodes []struct {
+					ID          string `json:"id"`
+					IsMinimized bool   `json:"isMinimized"`
+				} `json:"nodes"`
+			} `json:"data"`
+			Errors []struct {
+				Message string `json:"message"`
+			} `json:"errors"`
+		}{}
+		if _, err := ghClient.Do(ctx, req, &resp); err != nil {
+			return nil, fmt.Errorf("error querying minimized comments: %w", err)
+		}
+		if len(resp.Errors) > 0 {
+			return nil, fmt.Errorf("%w: querying minimized comments: %s", errInvalid, resp.Errors[0].Message)
+		}
+		for _, node := range resp.Data.Nodes {
+			if node.IsMinimized {
+				hidden[node.ID] = true
+			}
+		}
+		ids = ids[n:]
+	}
+	return hidden, nil
+}
+
+// minimizeComment hides a comment as outdated. The REST API doesn't support this, so we go through GraphQL.
+// GitHub API docs: https://docs.github.com/en/graphql/reference/mutations#minimizecomment
+func minimizeComment(ctx context.Context, ghClient *github.Client, nodeID string) error {
+	if nodeID == "" {
+		return fmt.Errorf("%w: comment node id", errEmpty)
+	}
+	body := map[string]interface{}{
+		"query": "mutation($id: ID!) { minimizeComment(input: {subjectId: $id, classifier: OUTDATED}) " +
+			"{ minimizedComment { isMinimized } } }",
+		"variables": map[string]string{"id": nodeID},
+	}
+	req, err := ghClient.NewRequest(http.MethodPost, "graphql", body)
+	if err != nil {
+		return fmt.Errorf("error creating graphql request: %w", err)
+	}
+	resp := struct {
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}{}
+	if _, err := ghClient.Do(ctx, req, &resp); err != nil {
+		return fmt.Errorf("error minimizing comment: %w", err)
+	}
+	if len(resp.Errors) > 0 {
+		return fmt.Errorf("%w: minimizing comment: %s", errInvalid, resp.Errors[0].Message)
+	}
+	return nil
+}
+
+// markdownOptions configures the dependency-diff markdown report.
+type markdownOptions struct {
+	decisions policyDecisions
+	depsDev   DepsDevClient
+	// depsDevBaseURL is the deps.dev instance the tags link to. Defaults to https://deps.dev.
+	depsDevBaseURL string
+	base           string
+	head           string
+	// checks are the columns of the per-check matrix. Defaults to the checks found in the results.
+	checks []string
+	// resultsFile is the file having the complete results, if any, to point at when the report is cut.
+	resultsFile string
+}
+
+// dependencydiffResultsAsMarkdown exports the dependencydiff results as markdown.
+func dependencydiffResultsAsMarkdown(ctx context.Context, depdiffResults []pkg.DependencyCheckResult,
+	opts *markdownOptions,
+) (*string, error) {
+	depsDevEntries := lookupDepsDevEntries(ctx, opts.depsDev, depdiffResults)
 	added, removed := dependencySliceToMaps(depdiffResults)
 	// Sort dependencies by their aggregate scores in descending orders.
 	addedSortKeys, err := getDependencySortKeys(added)
@@ -90,6 +275,10 @@ func dependencydiffResultsAsMarkdown(depdiffResults []pkg.DependencyCheckResult,
 	if err != nil {
 		return nil, err
 	}
+	updates, err := findUpdates(added, removed)
+	if err != nil {
+		return nil, err
+	}
 	sort.SliceStable(
 		addedSortKeys,
 		func(i, j int) bool { return addedSortKeys[i].aggregateScore > addedSortKeys[j].aggregateScore },
@@ -98,42 +287,40 @@ func dependencydiffResultsAsMarkdown(depdiffResults []pkg.DependencyCheckResult,
 		removedSortKeys,
 		func(i, j int) bool { return removedSortKeys[i].aggregateScore > removedSortKeys[j].aggregateScore },
 	)
-	results := ""
+	entries := []markdownEntry{}
+	risks := []riskyChange{}
 	for _, key := range addedSortKeys {
 		dName := key.dependencyName
 		if _, ok := added[dName]; !ok {
 			continue
 		}
-		current := addedTag()
-		if _, ok := removed[dName]; ok {
-			// Dependency in the added map also found in the removed map, indicating an updated one.
-			current += updatedTag()
-		}
 		newResult := added[dName]
+		risks = append(risks, newRiskyChange(&newResult, key.aggregateScore, updates, opts.decisions))
+		tag := ""
 		if newResult.Ecosystem != nil && newResult.Version != nil {
-			ok, err := entryExists(*newResult.Ecosystem, newResult.Name, *newResult.Version)
-			if err != nil {
-				return nil, err
-			}
-			if ok {
-				current += depsDevTag(*newResult.Ecosystem, newResult.Name)
+			p := DepsDevPackage{System: *newResult.Ecosystem, Name: newResult.Name, Version: *newResult.Version}
+			if depsDevEntries[p] {
+				tag = depsDevTag(opts.depsDevBaseURL, *newResult.Ecosystem, newResult.Name)
 			}
 		}
+		if u, ok := updates[dName]; ok {
+			current := updateAsMarkdown(&u, tag)
+			current += opts.decisions.policyTag(dName)
+			entries = append(entries, markdownEntry{ecosystem: valueOrEmpty(newResult.Ecosystem), text: current})
+			continue
+		}
+		current := addedTag() + tag
 		current += scoreTag(key.aggregateScore)
+		current += opts.decisions.policyTag(dName)
 
 		current += packageAsMarkdown(
 			newResult.Name, newResult.Version, newResult.SourceRepository, newResult.ChangeType,
 		)
-		if oldResult, ok := removed[dName]; ok {
-			current += packageAsMarkdown(
-				oldResult.Name, oldResult.Version, oldResult.SourceRepository, oldResult.ChangeType,
-			)
-		}
-		results += current + "\n\n"
+		entries = append(entries, markdownEntry{ecosystem: valueOrEmpty(newResult.Ecosystem), text: current})
 	}
 	for _, key := range removedSortKeys {
 		dName := key.dependencyName
-		if _, ok := added[dName]; ok {
+		if _, ok := updates[dName]; ok {
 			// Skip updated ones.
 			continue
 		}
@@ -148,20 +335,31 @@ func dependencydiffResultsAsMarkdown(depdiffResults []pkg.DependencyCheckResult,
 		current += packageAsMarkdown(
 			oldResult.Name, oldResult.Version, oldResult.SourceRepository, oldResult.ChangeType,
 		)
-		results += current + "\n\n"
+		entries = append(entries, markdownEntry{ecosystem: valueOrEmpty(oldResult.Ecosystem), text: current})
 	}
-	// TODO (#772):
-	out := "# [Scorecard Action](https://github.com/ossf/scorecard-action) Dependency-diff Report\n\n"
+	out := reportMarker + "\n"
+	out += "# [Scorecard Action](https://github.com/ossf/scorecard-action) Dependency-diff Report\n\n"
 	out += fmt.Sprintf(
 		"Dependency-diffs (changes) between the BASE reference `%s` and the HEAD reference `%s`:\n\n",
-		base, head,
+		opts.base, opts.head,
 	)
-	if results == "" {
+	footer := experimentalFeature()
+	if len(entries) == 0 {
 		out += fmt.Sprintln("No dependency changes found.")
-	} else {
-		out += fmt.Sprintln(results)
+		out += footer
+		return &out, nil
+	}
+	sortByRisk(risks)
+	out += riskiestChangesAsMarkdown(risks)
+	out += checkMatrixAsMarkdown(risks, opts.checks)
+	// Keep the report within the comment size limit, leaving room for the notice on what was left out.
+	budget := maxCommentLength - len(out) - len(footer) - omittedNoticeLength
+	sections, omitted := ecosystemSectionsAsMarkdown(entries, budget)
+	out += sections
+	if omitted > 0 {
+		out += omittedNotice(omitted, opts.resultsFile)
 	}
-	out += experimentalFeature()
+	out += footer
 	return &out, nil
 }
 
@@ -189,9 +387,15 @@ func experimentalFeature() string {
 	return result
 }
 
-func depsDevTag(system, name string) string {
+// depsDevTag links to the package on the deps.dev instance at baseURL, or on deps.dev if it's empty.
+func depsDevTag(baseURL, system, name string) string {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	if baseURL == "" {
+		baseURL = defaultDepsDevBaseURL
+	}
 	url := fmt.Sprintf(
-		"https://deps.dev/%s/%s",
+		"%s/%s/%s",
+		baseURL,
 		url.PathEscape(strings.ToLower(system)),
 		url.PathEscape(strings.ToLower(name)),
 	)
@@ -0,0 +1,337 @@
+// Copyright 2022 Security Scorecard Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//nolint
+package dependencydiff
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v45/github"
+)
+
+const (
+	testOwner    = "owner"
+	testRepo     = "repo"
+	testPRNumber = 1
+)
+
+// fakeGitHub is a minimal stand-in for the GitHub API endpoints used by the dependency-diff.
+type fakeGitHub struct {
+	mu        sync.Mutex
+	comments  []*github.IssueComment
+	minimized []string
+	nextID    int64
+	// login is the authenticated user, the GITHUB_TOKEN of a workflow can't read it when empty.
+	login string
+	// userStatus fails the requests for the authenticated user with the status, if set.
+	userStatus int
+	// hidden are the node IDs of the comments minimized before the run.
+	hidden []string
+
+	// checkRuns records the requests creating and updating the check runs.
+	checkRuns []checkRunRequest
+	// failAnnotations fails the updates of the check runs adding annotations.
+	failAnnotations bool
+	// contents are the files of the repository, keyed by ref and path, e.g. `base-sha:.github/scorecard-deps.yml`.
+	contents map[string]string
+}
+
+type checkRunRequest struct {
+	method      string
+	status      string
+	conclusion  string
+	annotations int
+}
+
+func (f *fakeGitHub) handler() http.Handler {
+	mux := http.NewServeMux()
+	commentsPath := fmt.Sprintf("/repos/%s/%s/issues/%d/comments", testOwner, testRepo, testPRNumber)
+	mux.HandleFunc(commentsPath, func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		switch r.Method {
+		case http.MethodGet:
+			json.NewEncoder(w).Encode(f.comments)
+		case http.MethodPost:
+			c := &github.IssueComment{}
+			json.NewDecoder(r.Body).Decode(c)
+			f.nextID++
+			c.ID = github.Int64(f.nextID)
+			c.NodeID = github.String(fmt.Sprintf("node-%d", f.nextID))
+			c.User = &github.User{Login: github.String(f.author())}
+			f.comments = append(f.comments, c)
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(c)
+		}
+	})
+	editPrefix := fmt.Sprintf("/repos/%s/%s/issues/comments/", testOwner, testRepo)
+	mux.HandleFunc(editPrefix, func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		id := strings.TrimPrefix(r.URL.Path, editPrefix)
+		edit := &github.IssueComment{}
+		json.NewDecoder(r.Body).Decode(edit)
+		for _, c := range f.comments {
+			if fmt.Sprint(c.GetID()) == id {
+				c.Body = edit.Body
+				json.NewEncoder(w).Encode(c)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	})
+	checkRunsPath := fmt.Sprintf("/repos/%s/%s/check-runs", testOwner, testRepo)
+	checkRunHandler := func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		opts := struct {
+			Status     string                 `json:"status"`
+			Conclusion string                 `json:"conclusion"`
+			Output     *github.CheckRunOutput `json:"output"`
+		}{}
+		json.NewDecoder(r.Body).Decode(&opts)
+		f.checkRuns = append(f.checkRuns, checkRunRequest{
+			method:      r.Method,
+			status:      opts.Status,
+			conclusion:  opts.Conclusion,
+			annotations: len(opts.Output.Annotations),
+		})
+		if f.failAnnotations && r.Method == http.MethodPatch && len(opts.Output.Annotations) > 0 {
+			http.Error(w, `{"message": "Server Error"}`, http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(&github.CheckRun{ID: github.Int64(1)})
+	}
+	mux.HandleFunc(checkRunsPath, checkRunHandler)
+	mux.HandleFunc(checkRunsPath+"/", checkRunHandler)
+	contentsPrefix := fmt.Sprintf("/repos/%s/%s/contents/", testOwner, testRepo)
+	mux.HandleFunc(contentsPrefix, func(w http.ResponseWriter, r *http.Request) {
+		key := r.URL.Query().Get("ref") + ":" + strings.TrimPrefix(r.URL.Path, contentsPrefix)
+		content, ok := f.contents[key]
+		if !ok {
+			http.Error(w, `{"message": "Not Found"}`, http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(&github.RepositoryContent{
+			Type:     github.String("file"),
+			Encoding: github.String("base64"),
+			Content:  github.String(base64.StdEncoding.EncodeToString([]byte(content))),
+		})
+	})
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		if f.userStatus != 0 {
+			http.Error(w, `{"message": "Server Error"}`, f.userStatus)
+			return
+		}
+		if f.login == "" {
+			http.Error(w, `{"message": "Resource not accessible by integration"}`, http.StatusForbidden)
+			return
+		}
+		json.NewEncoder(w).Encode(&github.User{Login: github.String(f.login)})
+	})
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		body := struct {
+			Query     string `json:"query"`
+			Variables struct {
+				ID  string   `json:"id"`
+				IDs []string `json:"ids"`
+			} `json:"variables"`
+		}{}
+		json.NewDecoder(r.Body).Decode(&body)
+		if strings.Contains(body.Query, "minimizeComment") {
+			f.minimized = append(f.minimized, body.Variables.ID)
+			fmt.Fprint(w, `{"data":{"minimizeComment":{"minimizedComment":{"isMinimized":true}}}}`)
+			return
+		}
+		nodes := []map[string]interface{}{}
+		for _, id := range body.Variables.IDs {
+			nodes = append(nodes, map[string]interface{}{"id": id, "isMinimized": f.isHidden(id)})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"nodes": nodes}})
+	})
+	return mux
+}
+
+func (f *fakeGitHub) author() string {
+	if f.login == "" {
+		return githubActionsLogin
+	}
+	return f.login
+}
+
+func (f *fakeGitHub) isHidden(nodeID string) bool {
+	for _, id := range append(f.hidden, f.minimized...) {
+		if id == nodeID {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *fakeGitHub) bodies() []string {
+	bodies := []string{}
+	for _, c := range f.comments {
+		bodies = append(bodies, c.GetBody())
+	}
+	return bodies
+}
+
+func newTestClient(t *testing.T, f *fakeGitHub) *github.Client {
+	t.Helper()
+	server := httptest.NewServer(f.handler())
+	t.Cleanup(server.Close)
+	ghClient := github.NewClient(nil)
+	baseURL, err := url.Parse(server.URL + "/")
+	if err != nil {
+		t.Fatalf("url.Parse: %v", err)
+	}
+	ghClient.BaseURL = baseURL
+	return ghClient
+}
+
+func Test_writeToComment(t *testing.T) {
+	t.Parallel()
+	oldReport := reportMarker + "\nold report"
+	newReport := reportMarker + "\nnew report"
+	bot := &github.User{Login: github.String(githubActionsLogin)}
+	someone := &github.User{Login: github.String("someone")}
+	tests := []struct {
+		name          string
+		mode          string
+		login         string
+		userStatus    int
+		existing      []*github.IssueComment
+		hidden        []string
+		wantBodies    []string
+		wantMinimized []string
+		wantErr       bool
+	}{
+		{
+			name:       "UpdateCreatesWhenNoReportExists",
+			mode:       commentModeUpdate,
+			existing:   []*github.IssueComment{{ID: github.Int64(100), User: someone, Body: github.String("LGTM")}},
+			wantBodies: []string{"LGTM", newReport},
+		},
+		{
+			name: "UpdateEditsPreviousReport",
+			mode: commentModeUpdate,
+			existing: []*github.IssueComment{
+				{ID: github.Int64(100), User: bot, Body: github.String(oldReport)},
+				{ID: github.Int64(101), User: someone, Body: github.String("LGTM")},
+			},
+			wantBodies: []string{newReport, "LGTM"},
+		},
+		{
+			name: "UpdateIgnoresReportsOfOthers",
+			mode: commentModeUpdate,
+			existing: []*github.IssueComment{
+				{ID: github.Int64(100), User: someone, Body: github.String(oldReport)},
+			},
+			wantBodies: []string{oldReport, newReport},
+		},
+		{
+			name:  "UpdateEditsReportOfAuthenticatedUser",
+			mode:  commentModeUpdate,
+			login: "someone",
+			existing: []*github.IssueComment{
+				{ID: github.Int64(100), User: someone, Body: github.String(oldReport)},
+				{ID: github.Int64(101), User: bot, Body: github.String(oldReport)},
+			},
+			wantBodies: []string{newReport, oldReport},
+		},
+		{
+			name: "MinimizeCollapsesPreviousReports",
+			mode: commentModeMinimize,
+			existing: []*github.IssueComment{
+				{ID: github.Int64(100), NodeID: github.String("node-100"), User: bot, Body: github.String(oldReport)},
+				{ID: github.Int64(101), NodeID: github.String("node-101"), User: bot, Body: github.String(oldReport)},
+			},
+			wantBodies:    []string{oldReport, oldReport, newReport},
+			wantMinimized: []string{"node-100", "node-101"},
+		},
+		{
+			name: "MinimizeSkipsHiddenReportsAndReportsOfOthers",
+			mode: commentModeMinimize,
+			existing: []*github.IssueComment{
+				{ID: github.Int64(100), NodeID: github.String("node-100"), User: bot, Body: github.String(oldReport)},
+				{ID: github.Int64(101), NodeID: github.String("node-101"), User: someone, Body: github.String(oldReport)},
+				{ID: github.Int64(102), NodeID: github.String("node-102"), User: bot, Body: github.String(oldReport)},
+			},
+			hidden:        []string{"node-100"},
+			wantBodies:    []string{oldReport, oldReport, oldReport, newReport},
+			wantMinimized: []string{"node-102"},
+		},
+		{
+			name:       "UserNotFound",
+			mode:       commentModeUpdate,
+			userStatus: http.StatusNotFound,
+			existing: []*github.IssueComment{
+				{ID: github.Int64(100), User: bot, Body: github.String(oldReport)},
+			},
+			wantBodies: []string{newReport},
+		},
+		{
+			name:       "FailureUserUnavailable",
+			mode:       commentModeMinimize,
+			userStatus: http.StatusBadGateway,
+			existing: []*github.IssueComment{
+				{ID: github.Int64(100), NodeID: github.String("node-100"), User: bot, Body: github.String(oldReport)},
+			},
+			wantErr: true,
+		},
+		{
+			name:    "InvalidMode",
+			mode:    "delete",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			f := &fakeGitHub{
+				comments: tt.existing, nextID: 1000, login: tt.login, userStatus: tt.userStatus, hidden: tt.hidden,
+			}
+			ghClient := newTestClient(t, f)
+			err := writeToComment(
+				context.Background(), ghClient, testOwner, testRepo, testPRNumber, &newReport, tt.mode,
+			)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("writeToComment() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !cmp.Equal(tt.wantBodies, f.bodies()) {
+				t.Errorf("comments: -want, +got:\n%s", cmp.Diff(tt.wantBodies, f.bodies()))
+			}
+			if len(tt.wantMinimized) > 0 && !cmp.Equal(tt.wantMinimized, f.minimized) {
+				t.Errorf("minimized: -want, +got:\n%s", cmp.Diff(tt.wantMinimized, f.minimized))
+			}
+		})
+	}
+}
@@ -0,0 +1,290 @@
+// Copyright 2022 Security Scorecard Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dependencydiff
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v45/github"
+	"gopkg.in/yaml.v3"
+
+	"github.com/ossf/scorecard/v4/checks"
+	"github.com/ossf/scorecard/v4/pkg"
+)
+
+const (
+	// defaultDependencyPolicyFile is looked up in the workspace when no policy file is given.
+	defaultDependencyPolicyFile = ".github/scorecard-deps.yml"
+	dependencyPolicyVersion     = 1
+	expiryLayout                = "2006-01-02"
+	// denylistCheckName is used in gate violations tripped by the denylist rather than a check score.
+	denylistCheckName = "Denylist"
+)
+
+// dependencyRule matches dependencies by name and, optionally, by ecosystem. Names support `*` wildcards,
+// and a trailing `/**` matches a whole namespace such as `@scope/**` or `github.com/org/**`.
+type dependencyRule struct {
+	Name      string `yaml:"name"`
+	Ecosystem string `yaml:"ecosystem"`
+	Reason    string `yaml:"reason"`
+}
+
+// scoreException relaxes the score thresholds for the matching dependencies until it expires.
+// An exception without minimum scores waives all the thresholds.
+type scoreException struct {
+	dependencyRule `yaml:",inline"`
+	MinScores      map[string]int `yaml:"min_scores"`
+	Expires        string         `yaml:"expires"`
+	Justification  string         `yaml:"justification"`
+	expiry         time.Time
+}
+
+// dependencyPolicy is the allow/deny policy for the dependency-diff.
+type dependencyPolicy struct {
+	Allow      []dependencyRule `yaml:"allow"`
+	Deny       []dependencyRule `yaml:"deny"`
+	Exceptions []scoreException `yaml:"exceptions"`
+	Version    int              `yaml:"version"`
+}
+
+// policyDecision is the outcome of the dependency policy for a dependency.
+type policyDecision struct {
+	denied           *dependencyRule
+	exception        *scoreException
+	expiredException *scoreException
+}
+
+// policyDecisions maps dependency names to their policy decisions.
+type policyDecisions map[string]policyDecision
+
+// readDependencyPolicy reads the dependency policy at the path relative to the workspace. If no path is given,
+// the default policy file is used if it exists. A nil policy is returned if there is no policy to apply.
+func readDependencyPolicy(workspace, policyFile string) (*dependencyPolicy, error) {
+	optional := policyFile == ""
+	if optional {
+		policyFile = defaultDependencyPolicyFile
+	}
+	content, err := os.ReadFile(filepath.Join(workspace, policyFile))
+	if err != nil {
+		if optional && errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error reading dependency policy file: %w", err)
+	}
+	return parseDependencyPolicy(content)
+}
+
+// readBaseDependencyPolicy reads the dependency policy of the repository at the base of a pull request,
+// since the workspace has the head of the pull request, which could change the policy it is checked against.
+// As readDependencyPolicy, the default policy file is used if no path is given and it exists.
+func readBaseDependencyPolicy(ctx context.Context, ghClient *github.Client, owner, repo, ref,
+	policyFile string,
+) (*dependencyPolicy, error) {
+	optional := policyFile == ""
+	if optional {
+		policyFile = defaultDependencyPolicyFile
+	}
+	file, _, resp, err := ghClient.Repositories.GetContents(
+		ctx, owner, repo, policyFile, &github.RepositoryContentGetOptions{Ref: ref},
+	)
+	if err != nil {
+		if optional && resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error reading dependency policy file at %s: %w", ref, err)
+	}
+	if file == nil {
+		return nil, fmt.Errorf("%w: dependency policy file %s is a directory", errInvalid, policyFile)
+	}
+	content, err := file.GetContent()
+	if err != nil {
+		return nil, fmt.Errorf("error decoding dependency policy file: %w", err)
+	}
+	return parseDependencyPolicy([]byte(content))
+}
+
+func parseDependencyPolicy(content []byte) (*dependencyPolicy, error) {
+	p := dependencyPolicy{}
+	if err := yaml.Unmarshal(content, &p); err != nil {
+		return nil, fmt.Errorf("error parsing dependency policy: %w", err)
+	}
+	if p.Version != dependencyPolicyVersion {
+		return nil, fmt.Errorf("%w: dependency policy version %d", errInvalid, p.Version)
+	}
+	rules := append(append([]dependencyRule{}, p.Allow...), p.Deny...)
+	for i := range p.Exceptions {
+		rules = append(rules, p.Exceptions[i].dependencyRule)
+	}
+	for _, r := range rules {
+		if r.Name == "" && r.Ecosystem == "" {
+			return nil, fmt.Errorf("%w: dependency policy rule without name and ecosystem", errInvalid)
+		}
+		if _, err := path.Match(r.Name, ""); err != nil {
+			return nil, fmt.Errorf("%w: dependency policy name pattern %s", errInvalid, r.Name)
+		}
+	}
+	allChecks := checks.GetAll()
+	for i := range p.Exceptions {
+		e := &p.Exceptions[i]
+		if e.Justification == "" {
+			return nil, fmt.Errorf("%w: exception for %s%s without justification", errEmpty, e.Ecosystem, e.Name)
+		}
+		if e.Expires == "" {
+			return nil, fmt.Errorf("%w: exception for %s%s without expiry date", errEmpty, e.Ecosystem, e.Name)
+		}
+		expiry, err := time.Parse(expiryLayout, e.Expires)
+		if err != nil {
+			return nil, fmt.Errorf("%w: exception expiry date %s", errInvalid, e.Expires)
+		}
+		// The exception is valid through the whole expiry day.
+		e.expiry = expiry.AddDate(0, 0, 1)
+		for name, score := range e.MinScores {
+			if _, ok := allChecks[name]; !ok && name != aggregateCheckName {
+				return nil, fmt.Errorf("%w: check name %s", errInvalid, name)
+			}
+			if score < 0 || score > 10 {
+				return nil, fmt.Errorf("%w: exception score %d for %s", errInvalid, score, name)
+			}
+		}
+	}
+	return &p, nil
+}
+
+// apply removes the allowlisted dependencies and returns the policy decisions for the remaining ones.
+func (p *dependencyPolicy) apply(deps []pkg.DependencyCheckResult, now time.Time,
+) ([]pkg.DependencyCheckResult, policyDecisions) {
+	decisions := policyDecisions{}
+	if p == nil {
+		return deps, decisions
+	}
+	kept := []pkg.DependencyCheckResult{}
+	for i := range deps {
+		d := deps[i]
+		if matchRules(p.Allow, &d) != nil {
+			// Logged to stderr, since the CLI writes the report to stdout.
+			fmt.Fprintf(os.Stderr, "skipping allowlisted dependency %s\n", d.Name)
+			continue
+		}
+		kept = append(kept, d)
+		decision := policyDecision{denied: matchRules(p.Deny, &d)}
+		for j := range p.Exceptions {
+			e := &p.Exceptions[j]
+			if !e.matches(&d) {
+				continue
+			}
+			if now.Before(e.expiry) {
+				decision.exception = e
+				break
+			}
+			if decision.expiredException == nil {
+				decision.expiredException = e
+			}
+		}
+		if decision.denied != nil || decision.exception != nil || decision.expiredException != nil {
+			decisions[d.Name] = decision
+		}
+	}
+	return kept, decisions
+}
+
+// threshold returns the score threshold of the check after applying the exception, and whether the
+// check is still gated.
+func (e *scoreException) threshold(check string, gated float64) (float64, bool) {
+	if e == nil {
+		return gated, true
+	}
+	if len(e.MinScores) == 0 {
+		return 0, false
+	}
+	if score, ok := e.MinScores[check]; ok {
+		return float64(score), true
+	}
+	return gated, true
+}
+
+func (r *dependencyRule) matches(d *pkg.DependencyCheckResult) bool {
+	if r.Ecosystem != "" && (d.Ecosystem == nil || !strings.EqualFold(r.Ecosystem, *d.Ecosystem)) {
+		return false
+	}
+	if r.Name == "" {
+		return true
+	}
+	name, pattern := strings.ToLower(d.Name), strings.ToLower(r.Name)
+	if strings.HasSuffix(pattern, "/**") {
+		return strings.HasPrefix(name, strings.TrimSuffix(pattern, "**"))
+	}
+	matched, err := path.Match(pattern, name)
+	return err == nil && matched
+}
+
+func matchRules(rules []dependencyRule, d *pkg.DependencyCheckResult) *dependencyRule {
+	for i := range rules {
+		if rules[i].matches(d) {
+			return &rules[i]
+		}
+	}
+	return nil
+}
+
+// policyTag returns a markdown tag for the policy decision of the dependency.
+func (decisions policyDecisions) policyTag(name string) string {
+	decision, ok := decisions[name]
+	if !ok {
+		return ""
+	}
+	switch {
+	case decision.denied != nil:
+		return " :no_entry: **`denied`** "
+	case decision.exception != nil:
+		return fmt.Sprintf(" **`policy exception until %s`** ", decision.exception.Expires)
+	case decision.expiredException != nil:
+		return fmt.Sprintf(" :warning: **`policy exception expired on %s`** ", decision.expiredException.Expires)
+	default:
+		return ""
+	}
+}
+
+// policyMessage returns a note on the policy decision of the dependency for the annotations.
+func (decisions policyDecisions) policyMessage(name string) string {
+	decision, ok := decisions[name]
+	if !ok {
+		return ""
+	}
+	switch {
+	case decision.denied != nil:
+		return fmt.Sprintf("\nPolicy: denied by the dependency policy. %s", decision.denied.Reason)
+	case decision.exception != nil:
+		return fmt.Sprintf(
+			"\nPolicy: score exception applied until %s. %s",
+			decision.exception.Expires, decision.exception.Justification,
+		)
+	case decision.expiredException != nil:
+		return fmt.Sprintf(
+			"\nPolicy: score exception expired on %s and was not applied. %s",
+			decision.expiredException.Expires, decision.expiredException.Justification,
+		)
+	default:
+		return ""
+	}
+}
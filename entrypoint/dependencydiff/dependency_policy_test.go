@@ -0,0 +1,193 @@
+// Copyright 2022 Security Scorecard Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//nolint
+package dependencydiff
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ossf/scorecard/v4/pkg"
+)
+
+func Test_readDependencyPolicy(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		workspace  string
+		policyFile string
+		wantNil    bool
+		wantErr    bool
+	}{
+		{name: "DefaultFile", workspace: "testdata"},
+		{name: "NoDefaultFile", workspace: testManifestsDir, wantNil: true},
+		{name: "FailureMissingGivenFile", workspace: "testdata", policyFile: "missing.yml", wantErr: true},
+		{name: "FailureNotAPolicy", workspace: testManifestsDir, policyFile: "package.json", wantErr: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := readDependencyPolicy(tt.workspace, tt.policyFile)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readDependencyPolicy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (got == nil) != tt.wantNil {
+				t.Errorf("readDependencyPolicy() = %v, wantNil %v", got, tt.wantNil)
+			}
+		})
+	}
+}
+
+func Test_readBaseDependencyPolicy(t *testing.T) {
+	t.Parallel()
+	basePolicy := "version: 1\ndeny:\n  - name: evil-lib\n    reason: malware"
+	// The head of the pull request allows the denied dependency.
+	headPolicy := "version: 1\nallow:\n  - name: evil-lib\n    reason: trust me"
+	f := &fakeGitHub{contents: map[string]string{
+		"base-sha:" + defaultDependencyPolicyFile: basePolicy,
+		"head-sha:" + defaultDependencyPolicyFile: headPolicy,
+		"head-sha:custom.yml":                     headPolicy,
+	}}
+	ghClient := newTestClient(t, f)
+	tests := []struct {
+		name       string
+		policyFile string
+		wantDeny   []string
+		wantErr    bool
+	}{
+		{name: "DefaultFile", wantDeny: []string{"evil-lib"}},
+		{name: "FailureFileOnlyInHead", policyFile: "custom.yml", wantErr: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := readBaseDependencyPolicy(
+				context.Background(), ghClient, testOwner, testRepo, "base-sha", tt.policyFile,
+			)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readBaseDependencyPolicy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(got.Allow) != 0 {
+				t.Errorf("allow = %v, want the rules of the base only", got.Allow)
+			}
+			gotDeny := []string{}
+			for _, r := range got.Deny {
+				gotDeny = append(gotDeny, r.Name)
+			}
+			if !cmp.Equal(tt.wantDeny, gotDeny) {
+				t.Errorf("deny: -want, +got:\n%s", cmp.Diff(tt.wantDeny, gotDeny))
+			}
+		})
+	}
+	t.Run("NoDefaultFile", func(t *testing.T) {
+		t.Parallel()
+		got, err := readBaseDependencyPolicy(context.Background(), ghClient, testOwner, testRepo, "other-sha", "")
+		if err != nil || got != nil {
+			t.Errorf("readBaseDependencyPolicy() = %v, %v, want no policy", got, err)
+		}
+	})
+}
+
+func Test_parseDependencyPolicy(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "WrongVersion", content: "version: 2"},
+		{name: "EmptyRule", content: "version: 1\ndeny:\n  - reason: nothing to match"},
+		{name: "NoJustification", content: "version: 1\nexceptions:\n  - name: a\n    expires: \"2022-01-01\""},
+		{name: "NoExpiry", content: "version: 1\nexceptions:\n  - name: a\n    justification: because"},
+		{name: "BadExpiry", content: "version: 1\nexceptions:\n  - name: a\n    justification: b\n    expires: soon"},
+		{
+			name:    "UnknownCheck",
+			content: "version: 1\nexceptions:\n  - name: a\n    justification: b\n    expires: \"2022-01-01\"\n    min_scores:\n      Not-A-Check: 1",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if _, err := parseDependencyPolicy([]byte(tt.content)); err == nil {
+				t.Errorf("parseDependencyPolicy() error = nil, want an error")
+			}
+		})
+	}
+}
+
+func Test_dependencyPolicy_apply(t *testing.T) {
+	t.Parallel()
+	p, err := readDependencyPolicy("testdata", "")
+	if err != nil {
+		t.Fatalf("readDependencyPolicy: %v", err)
+	}
+	withEcosystem := func(d pkg.DependencyCheckResult, ecosystem string) pkg.DependencyCheckResult {
+		d.Ecosystem = &ecosystem
+		return d
+	}
+	scores := map[string]int{"Maintained": 2, "Code-Review": 10}
+	deps := []pkg.DependencyCheckResult{
+		withEcosystem(testDependency("github.com/ossf/scorecard", pkg.Added, scores), "GO"),
+		withEcosystem(testDependency("@evil/pkg", pkg.Added, nil), "NPM"),
+		withEcosystem(testDependency("@good/pkg", pkg.Added, scores), "NPM"),
+		withEcosystem(testDependency("legacy-lib", pkg.Added, scores), "NPM"),
+		withEcosystem(testDependency("requests", pkg.Added, scores), "PYPI"),
+	}
+	// The legacy-lib exception is still valid, the PYPI one has expired.
+	now := time.Date(2022, time.December, 31, 12, 0, 0, 0, time.UTC)
+	kept, decisions := p.apply(deps, now)
+
+	gotKept := []string{}
+	for _, d := range kept {
+		gotKept = append(gotKept, d.Name)
+	}
+	wantKept := []string{"@evil/pkg", "@good/pkg", "legacy-lib", "requests"}
+	if !cmp.Equal(wantKept, gotKept) {
+		t.Errorf("kept: -want, +got:\n%s", cmp.Diff(wantKept, gotKept))
+	}
+	if decisions["@evil/pkg"].denied == nil {
+		t.Errorf("@evil/pkg should be denied")
+	}
+	if decisions["legacy-lib"].exception == nil {
+		t.Errorf("legacy-lib should have an exception")
+	}
+	if decisions["requests"].expiredException == nil || decisions["requests"].exception != nil {
+		t.Errorf("requests should have an expired exception only")
+	}
+
+	gate, err := parseScoreGate("", "Maintained>=5")
+	if err != nil {
+		t.Fatalf("parseScoreGate: %v", err)
+	}
+	violations, err := gate.evaluate(kept, decisions)
+	if err != nil {
+		t.Fatalf("evaluate: %v", err)
+	}
+	got := []string{}
+	for _, v := range violations {
+		got = append(got, v.dependency+":"+v.check)
+	}
+	want := []string{"@evil/pkg:" + denylistCheckName, "@good/pkg:Maintained", "requests:Maintained"}
+	if !cmp.Equal(want, got) {
+		t.Errorf("violations: -want, +got:\n%s", cmp.Diff(want, got))
+	}
+}
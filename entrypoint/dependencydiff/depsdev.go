@@ -0,0 +1,243 @@
+// Copyright 2022 Security Scorecard Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dependencydiff
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ossf/scorecard/v4/pkg"
+)
+
+const (
+	defaultDepsDevBaseURL = "https://deps.dev"
+	depsDevWorkers        = 8
+	depsDevTimeout        = 10 * time.Second
+	depsDevRetries        = 3
+	depsDevRetryBackoff   = 500 * time.Millisecond
+)
+
+// DepsDevPackage is a package version looked up on deps.dev.
+type DepsDevPackage struct {
+	System  string
+	Name    string
+	Version string
+}
+
+// DepsDevClient looks up package versions on deps.dev.
+type DepsDevClient interface {
+	// EntryExists reports whether deps.dev has an entry for the package version.
+	EntryExists(ctx context.Context, p DepsDevPackage) (bool, error)
+}
+
+// DepsDevOptions configures the deps.dev client returned by NewDepsDevClient.
+type DepsDevOptions struct {
+	// BaseURL is the deps.dev instance to query, e.g. a mirror on air-gapped runners.
+	// Defaults to https://deps.dev.
+	BaseURL string
+	// CacheDir, if set, keeps the found entries on disk across runs.
+	CacheDir string
+	// HTTPClient is used to send the requests. Defaults to a client with a timeout.
+	HTTPClient *http.Client
+}
+
+type depsDevClient struct {
+	baseURL      string
+	cacheDir     string
+	httpClient   *http.Client
+	retryBackoff time.Duration
+
+	mu    sync.Mutex
+	cache map[DepsDevPackage]bool
+}
+
+// NewDepsDevClient creates a deps.dev client caching the lookups in memory for the run
+// and, if asked, on disk.
+func NewDepsDevClient(opts DepsDevOptions) DepsDevClient {
+	c := &depsDevClient{
+		baseURL:      strings.TrimSuffix(opts.BaseURL, "/"),
+		cacheDir:     opts.CacheDir,
+		httpClient:   opts.HTTPClient,
+		retryBackoff: depsDevRetryBackoff,
+		cache:        map[DepsDevPackage]bool{},
+	}
+	if c.baseURL == "" {
+		c.baseURL = defaultDepsDevBaseURL
+	}
+	if c.httpClient == nil {
+		c.httpClient = &http.Client{Timeout: depsDevTimeout}
+	}
+	return c
+}
+
+// EntryExists implements DepsDevClient.
+func (c *depsDevClient) EntryExists(ctx context.Context, p DepsDevPackage) (bool, error) {
+	c.mu.Lock()
+	exists, ok := c.cache[p]
+	c.mu.Unlock()
+	if ok {
+		return exists, nil
+	}
+	entryURL := fmt.Sprintf(
+		"%s/_/s/%s/p/%s/v/%s",
+		c.baseURL,
+		url.PathEscape(p.System),
+		url.PathEscape(p.Name),
+		url.PathEscape(p.Version),
+	)
+	cacheFile := c.cacheFile(entryURL)
+	if cacheFile != "" {
+		if _, err := os.Stat(cacheFile); err == nil {
+			c.store(p, true)
+			return true, nil
+		}
+	}
+	exists, err := c.get(ctx, entryURL)
+	if err != nil {
+		return false, err
+	}
+	c.store(p, exists)
+	// Only the found entries go to the disk cache, since a missing one may be indexed later.
+	if exists && cacheFile != "" {
+		if err := os.MkdirAll(c.cacheDir, 0o755); err != nil {
+			return false, fmt.Errorf("error creating the deps.dev cache dir: %w", err)
+		}
+		if err := os.WriteFile(cacheFile, []byte(entryURL), 0o600); err != nil {
+			return false, fmt.Errorf("error writing the deps.dev cache: %w", err)
+		}
+	}
+	return exists, nil
+}
+
+// get requests the deps.dev entry, retrying on transport errors, rate limiting and server errors.
+func (c *depsDevClient) get(ctx context.Context, entryURL string) (bool, error) {
+	var lastErr error
+	for attempt := 0; attempt <= depsDevRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return false, fmt.Errorf("error requesting deps.dev: %w", ctx.Err())
+			case <-time.After(time.Duration(attempt) * c.retryBackoff):
+			}
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, entryURL, nil)
+		if err != nil {
+			return false, fmt.Errorf("error creating the deps.dev request: %w", err)
+		}
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			if errors.Is(err, context.Canceled) {
+				return false, fmt.Errorf("error requesting deps.dev: %w", err)
+			}
+			lastErr = err
+			continue
+		}
+		// Drain the body so the connection can be reused.
+		io.Copy(io.Discard, resp.Body) //nolint:errcheck
+		resp.Body.Close()
+		switch {
+		case resp.StatusCode == http.StatusOK:
+			return true, nil
+		case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError:
+			lastErr = fmt.Errorf("%w: deps.dev status %d", errInvalid, resp.StatusCode)
+			continue
+		default:
+			return false, nil
+		}
+	}
+	return false, fmt.Errorf("error requesting deps.dev: %w", lastErr)
+}
+
+func (c *depsDevClient) store(p DepsDevPackage, exists bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cache[p] = exists
+}
+
+func (c *depsDevClient) cacheFile(entryURL string) string {
+	if c.cacheDir == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(entryURL))
+	return filepath.Join(c.cacheDir, hex.EncodeToString(sum[:]))
+}
+
+// lookupDepsDevEntries looks up the added dependencies on deps.dev with a bounded number of workers.
+// A nil client skips the lookups. The deps.dev links are only a convenience, so the packages which
+// can't be looked up are logged and left out rather than failing the report.
+func lookupDepsDevEntries(ctx context.Context, client DepsDevClient, deps []pkg.DependencyCheckResult,
+) map[DepsDevPackage]bool {
+	found := map[DepsDevPackage]bool{}
+	if client == nil {
+		return found
+	}
+	pkgs := []DepsDevPackage{}
+	seen := map[DepsDevPackage]bool{}
+	for i := range deps {
+		d := deps[i]
+		if d.ChangeType == nil || *d.ChangeType == pkg.Removed || d.Ecosystem == nil || d.Version == nil {
+			continue
+		}
+		p := DepsDevPackage{System: *d.Ecosystem, Name: d.Name, Version: *d.Version}
+		if !seen[p] {
+			seen[p] = true
+			pkgs = append(pkgs, p)
+		}
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	jobs := make(chan DepsDevPackage)
+	workers := depsDevWorkers
+	if len(pkgs) < workers {
+		workers = len(pkgs)
+	}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range jobs {
+				exists, err := client.EntryExists(ctx, p)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "skipping the deps.dev entry of %s@%s: %v\n", p.Name, p.Version, err)
+				}
+				mu.Lock()
+				if exists {
+					found[p] = true
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, p := range pkgs {
+		jobs <- p
+	}
+	close(jobs)
+	wg.Wait()
+	return found
+}
@@ -0,0 +1,191 @@
+// Copyright 2022 Security Scorecard Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//nolint
+package dependencydiff
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ossf/scorecard/v4/pkg"
+)
+
+// fakeDepsDev is a local stand-in for deps.dev that knows the entries in known,
+// and fails the first failures requests for each of them.
+type fakeDepsDev struct {
+	mu       sync.Mutex
+	known    map[string]bool
+	failures int
+	requests map[string]int
+}
+
+func (f *fakeDepsDev) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests[r.URL.Path]++
+	switch {
+	case f.requests[r.URL.Path] <= f.failures:
+		w.WriteHeader(http.StatusServiceUnavailable)
+	case f.known[r.URL.Path]:
+		w.WriteHeader(http.StatusOK)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (f *fakeDepsDev) total() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for _, c := range f.requests {
+		n += c
+	}
+	return n
+}
+
+func newTestDepsDev(t *testing.T, failures int, cacheDir string) (*fakeDepsDev, DepsDevClient) {
+	t.Helper()
+	fake := &fakeDepsDev{
+		known: map[string]bool{
+			"/_/s/npm/p/left-pad/v/1.3.0": true,
+			"/_/s/go/p/github.com/owner/lib/v/v1.0.0": true,
+		},
+		failures: failures,
+		requests: map[string]int{},
+	}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	client := NewDepsDevClient(DepsDevOptions{BaseURL: server.URL + "/", CacheDir: cacheDir})
+	client.(*depsDevClient).retryBackoff = 0
+	return fake, client
+}
+
+func Test_depsDevClient_EntryExists(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		pkg      DepsDevPackage
+		failures int
+		want     bool
+	}{
+		{
+			name: "known entry",
+			pkg:  DepsDevPackage{System: "npm", Name: "left-pad", Version: "1.3.0"},
+			want: true,
+		},
+		{
+			name: "unknown entry",
+			pkg:  DepsDevPackage{System: "npm", Name: "left-pad", Version: "0.0.1"},
+			want: false,
+		},
+		{
+			name:     "retried after server errors",
+			pkg:      DepsDevPackage{System: "npm", Name: "left-pad", Version: "1.3.0"},
+			failures: depsDevRetries,
+			want:     true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			fake, client := newTestDepsDev(t, tt.failures, "")
+			for i := 0; i < 2; i++ {
+				got, err := client.EntryExists(context.Background(), tt.pkg)
+				if err != nil {
+					t.Fatalf("EntryExists: %v", err)
+				}
+				if got != tt.want {
+					t.Errorf("EntryExists() = %v, want %v", got, tt.want)
+				}
+			}
+			// The second lookup is served from the in-memory cache.
+			if got, want := fake.total(), tt.failures+1; got != want {
+				t.Errorf("got %d requests, want %d", got, want)
+			}
+		})
+	}
+}
+
+func Test_depsDevClient_EntryExists_retriesExhausted(t *testing.T) {
+	t.Parallel()
+	_, client := newTestDepsDev(t, depsDevRetries+1, "")
+	_, err := client.EntryExists(context.Background(), DepsDevPackage{System: "npm", Name: "left-pad", Version: "1.3.0"})
+	if err == nil {
+		t.Error("EntryExists() succeeded, want an error")
+	}
+}
+
+func Test_depsDevClient_diskCache(t *testing.T) {
+	t.Parallel()
+	cacheDir := t.TempDir()
+	p := DepsDevPackage{System: "npm", Name: "left-pad", Version: "1.3.0"}
+	_, first := newTestDepsDev(t, 0, cacheDir)
+	if _, err := first.EntryExists(context.Background(), p); err != nil {
+		t.Fatalf("EntryExists: %v", err)
+	}
+	fake, second := newTestDepsDev(t, 0, cacheDir)
+	// The second client has a new server URL, so point it at the first one's cache key.
+	second.(*depsDevClient).baseURL = first.(*depsDevClient).baseURL
+	got, err := second.EntryExists(context.Background(), p)
+	if err != nil {
+		t.Fatalf("EntryExists: %v", err)
+	}
+	if !got || fake.total() != 0 {
+		t.Errorf("EntryExists() = %v with %d requests, want true from the disk cache", got, fake.total())
+	}
+}
+
+func Test_lookupDepsDevEntries(t *testing.T) {
+	t.Parallel()
+	withEcosystem := func(d pkg.DependencyCheckResult, ecosystem string) pkg.DependencyCheckResult {
+		d.Ecosystem = &ecosystem
+		return d
+	}
+	deps := []pkg.DependencyCheckResult{
+		withEcosystem(testDependency("github.com/owner/lib", pkg.Added, nil), "go"),
+		withEcosystem(testDependency("github.com/owner/other", pkg.Added, nil), "go"),
+		withEcosystem(testDependency("github.com/owner/gone", pkg.Removed, nil), "go"),
+		testDependency("no-ecosystem", pkg.Added, nil),
+	}
+	fake, client := newTestDepsDev(t, 0, "")
+	got := lookupDepsDevEntries(context.Background(), client, deps)
+	want := map[DepsDevPackage]bool{
+		{System: "go", Name: "github.com/owner/lib", Version: "v1.0.0"}: true,
+	}
+	if !cmp.Equal(want, got) {
+		t.Errorf("-want, +got:\n%s", cmp.Diff(want, got))
+	}
+	if fake.total() != 2 {
+		t.Errorf("got %d requests, want 2", fake.total())
+	}
+
+	if got := lookupDepsDevEntries(context.Background(), nil, deps); len(got) != 0 {
+		t.Errorf("lookupDepsDevEntries(nil) = %v, want no entries", got)
+	}
+
+	// deps.dev failing after the retries leaves the packages out instead of failing the lookups.
+	fake, client = newTestDepsDev(t, depsDevRetries+1, "")
+	if got := lookupDepsDevEntries(context.Background(), client, deps); len(got) != 0 {
+		t.Errorf("lookupDepsDevEntries() = %v with deps.dev failing, want no entries", got)
+	}
+	if want := 2 * (depsDevRetries + 1); fake.total() != want {
+		t.Errorf("got %d requests, want %d", fake.total(), want)
+	}
+}
@@ -19,9 +19,12 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/google/go-github/v45/github"
+	actiongithub "github.com/ossf/scorecard-action/github"
 	"github.com/ossf/scorecard-action/options"
 	"github.com/ossf/scorecard/v4/clients/githubrepo/roundtripper"
 	"github.com/ossf/scorecard/v4/dependencydiff"
@@ -29,60 +32,261 @@ import (
 	"github.com/ossf/scorecard/v4/pkg"
 )
 
+// runConfig is the configuration of a dependency-diff run, shared by the action and the CLI.
+type runConfig struct {
+	repoURI     string
+	base        string
+	head        string
+	checks      []string
+	changeTypes []string
+	gate        *scoreGate
+	policy      *dependencyPolicy
+}
+
+// runResults are the dependency-diffs left after applying the dependency policy, with the score gate violations.
+type runResults struct {
+	deps       []pkg.DependencyCheckResult
+	decisions  policyDecisions
+	violations []gateViolation
+}
+
 // New creates a new instance running the scorecard dependency-diff mode
-// used as an entrypoint for GitHub Actions.
-func New(ctx context.Context) error {
+// used as an entrypoint for GitHub Actions, for the pull request of the event which triggered the workflow.
+func New(ctx context.Context, event *actiongithub.Event) error {
 	repoURI := os.Getenv(options.EnvGithubRepository)
 	ownerRepo := strings.Split(repoURI, "/")
 	if len(ownerRepo) != 2 {
 		return fmt.Errorf("%w: repo uri", errInvalid)
 	}
+	if event == nil {
+		return fmt.Errorf("%w: event", errEmpty)
+	}
+	prNumber := event.PullRequestNumber()
+	if prNumber == 0 {
+		return fmt.Errorf("%w: pull request number", errEmpty)
+	}
 	// Since the event listener is set to pull requests to main, this will be the main branch reference.
-	base := os.Getenv(options.EnvGithubBaseRef)
+	base := event.BaseRef()
 	if base == "" {
 		return fmt.Errorf("%w: base ref", errEmpty)
 	}
 	// The head reference of the pull request source branch.
-	head := os.Getenv(options.EnvGitHubHeadRef)
+	head := event.HeadRef()
 	if head == "" {
 		return fmt.Errorf("%w: head ref", errEmpty)
 	}
+	// The check run goes on the head commit of the pull request, unless another one is given.
+	headSHA := os.Getenv(options.EnvInputPullRequestHeadSHA)
+	if headSHA == "" {
+		headSHA = event.HeadSHA()
+	}
+	commentMode := os.Getenv(options.EnvInputCommentMode)
+	if commentMode == "" {
+		commentMode = commentModeUpdate
+	}
+	if commentMode != commentModeUpdate && commentMode != commentModeMinimize {
+		return fmt.Errorf("%w: comment mode", errInvalid)
+	}
+	gate, err := parseScoreGate(
+		os.Getenv(options.EnvInputMinAggregateScore), os.Getenv(options.EnvInputMinCheckScores),
+	)
+	if err != nil {
+		return err
+	}
 	// GetDependencyDiffResults will handle the error checking of checks.
 	checks := strings.Split(os.Getenv(options.EnvInputChecks), ",")
-	changeTypes := strings.Split(os.Getenv(options.EnvInputChangeTypes), ",")
+	workspace := os.Getenv(options.EnvGithubWorkspace)
+	logger := log.NewLogger(log.DefaultLevel)
+	ghrt := roundtripper.NewTransport(ctx, logger) /* This round tripper handles the access token. */
+	ghClient := github.NewClient(&http.Client{Transport: ghrt})
+	// The policy is read at the base of the pull request, so that the pull request can't change it.
+	baseSHA := event.BaseSHA()
+	if baseSHA == "" {
+		baseSHA = base
+	}
+	depPolicy, err := readBaseDependencyPolicy(
+		ctx, ghClient, ownerRepo[0], ownerRepo[1], baseSHA, os.Getenv(options.EnvInputDependencyPolicyFile),
+	)
+	if err != nil {
+		return err
+	}
+	results, err := runDependencyDiff(ctx, &runConfig{
+		repoURI:     repoURI,
+		base:        base,
+		head:        head,
+		checks:      checks,
+		changeTypes: strings.Split(os.Getenv(options.EnvInputChangeTypes), ","),
+		gate:        gate,
+		policy:      depPolicy,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Generate a markdown string using the dependency-diffs, to be written to the sinks.
+	resultsFile := os.Getenv(options.EnvInputDependencyDiffResultsFile)
+	depsDevBaseURL := os.Getenv(options.EnvInputDepsDevBaseURL)
+	depsDev := NewDepsDevClient(DepsDevOptions{
+		BaseURL:  depsDevBaseURL,
+		CacheDir: os.Getenv(options.EnvInputDepsDevCacheDir),
+	})
+	markdown, err := dependencydiffResultsAsMarkdown(ctx, results.deps, &markdownOptions{
+		decisions:      results.decisions,
+		depsDev:        depsDev,
+		depsDevBaseURL: depsDevBaseURL,
+		base:           base,
+		head:           head,
+		checks:         checks,
+		resultsFile:    resultsFile,
+	})
+	report := &Report{
+		Base:         base,
+		Head:         head,
+		Dependencies: results.deps,
+		decisions:    results.decisions,
+		violations:   results.violations,
+		gate:         gate,
+	}
+	// A markdown error only fails the sinks writing the markdown, the other ones don't need it.
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error formatting results as markdown: %v\n", err)
+		report.markdownErr = err
+	} else {
+		report.Markdown = *markdown
+	}
+
+	sinks, err := newSinks(os.Getenv(options.EnvInputDependencyDiffSinks), &sinkConfig{
+		ghClient:        ghClient,
+		owner:           ownerRepo[0],
+		repo:            ownerRepo[1],
+		prNumber:        prNumber,
+		headSHA:         headSHA,
+		commentMode:     commentMode,
+		stepSummaryFile: os.Getenv(options.EnvGithubStepSummary),
+		reportFile:      workspaceFile(workspace, os.Getenv(options.EnvInputDependencyDiffReportFile)),
+		webhookURL:      os.Getenv(options.EnvInputDependencyDiffWebhookURL),
+	})
+	if err != nil {
+		return err
+	}
+	// Give the complete dependency-diff results as a JSON file for the downstream steps, if asked.
+	if resultsFile != "" {
+		sinks = append(sinks, &fileSink{
+			name: "results-file", path: workspaceFile(workspace, resultsFile), format: formatJSON,
+		})
+	}
+	// Give the low-scoring dependencies as a SARIF log to be uploaded to code scanning, if asked.
+	if sarifFile := os.Getenv(options.EnvInputDependencyDiffSarifFile); sarifFile != "" {
+		sinks = append(sinks, &fileSink{
+			name: "sarif-file", path: workspaceFile(workspace, sarifFile), format: formatSARIF, workspace: workspace,
+		})
+	}
+	sinkErr := writeToSinks(ctx, sinks, report)
+	// The outputs are written even if a sink failed, since the downstream steps may not need it.
+	if outputFile := os.Getenv(options.EnvGithubOutput); outputFile != "" {
+		if err := writeDependencyDiffOutputs(outputFile, results.deps); err != nil {
+			if sinkErr != nil {
+				return fmt.Errorf("%w, and %v", sinkErr, err)
+			}
+			return err
+		}
+	}
+	if sinkErr != nil {
+		return sinkErr
+	}
+	if len(results.violations) > 0 {
+		return fmt.Errorf("%w: %d violation(s)", errScoreThreshold, len(results.violations))
+	}
+	return nil
+}
+
+func writeDependencyDiffOutputs(outputFile string, deps []pkg.DependencyCheckResult) error {
+	outputs, err := dependencydiffOutputs(deps)
+	if err != nil {
+		return err
+	}
+	if err := actiongithub.WriteOutputs(outputFile, outputs); err != nil {
+		return fmt.Errorf("error writing outputs: %w", err)
+	}
+	return nil
+}
+
+// workspaceFile returns the path of a file given relative to the workspace, or "" if no file is given.
+func workspaceFile(workspace, file string) string {
+	if file == "" {
+		return ""
+	}
+	return filepath.Join(workspace, file)
+}
+
+// changeTypesToFetch returns the change types to get the dependency-diffs and Scorecard results of.
+func changeTypesToFetch(changeTypes []string) (map[pkg.ChangeType]bool, error) {
 	changeTypeMap := map[pkg.ChangeType]bool{}
 	for _, ct := range changeTypes {
 		key := pkg.ChangeType(ct)
 		if !key.IsValid() {
-			return fmt.Errorf("%w: change type", errInvalid)
+			return nil, fmt.Errorf("%w: change type", errInvalid)
 		}
 		changeTypeMap[key] = true
 	}
-	deps, err := dependencydiff.GetDependencyDiffResults(
-		ctx, repoURI, base, head, checks, changeTypeMap,
-	)
-	if err != nil {
-		return fmt.Errorf("error getting dependency-diff: %w", err)
+	if changeTypeMap[pkg.Updated] {
+		// Updates are reported as added and removed dependencies by the data source, so both the new
+		// and the old versions need their Scorecard results to compare the scores.
+		changeTypeMap[pkg.Added] = true
+		changeTypeMap[pkg.Removed] = true
+	}
+	return changeTypeMap, nil
+}
+
+// filterChangeTypes keeps the dependency-diffs of the requested change types. The added and removed dependencies
+// fetched to find the updates are kept only if they are part of an update.
+func filterChangeTypes(deps []pkg.DependencyCheckResult, requested map[pkg.ChangeType]bool,
+) []pkg.DependencyCheckResult {
+	added, removed := dependencySliceToMaps(deps)
+	paired := func(d *pkg.DependencyCheckResult, others map[string]pkg.DependencyCheckResult) bool {
+		other, ok := others[d.Name]
+		return ok && sameEcosystem(&other, d)
+	}
+	filtered := []pkg.DependencyCheckResult{}
+	for i := range deps {
+		d := deps[i]
+		if d.ChangeType != nil && !requested[*d.ChangeType] {
+			switch {
+			case requested[pkg.Updated] && *d.ChangeType == pkg.Added && paired(&d, removed):
+			case requested[pkg.Updated] && *d.ChangeType == pkg.Removed && paired(&d, added):
+			default:
+				continue
+			}
+		}
+		filtered = append(filtered, d)
 	}
+	return filtered
+}
 
-	// Generate a markdown string using the dependency-diffs and write it to the pull request comment.
-	report, err := dependencydiffResultsAsMarkdown(deps, base, head)
+// runDependencyDiff gets the dependency-diffs with their Scorecard results, then applies the dependency policy
+// and the score gate to them.
+func runDependencyDiff(ctx context.Context, cfg *runConfig) (*runResults, error) {
+	changeTypeMap, err := changeTypesToFetch(cfg.changeTypes)
 	if err != nil {
-		return fmt.Errorf("error formatting results as markdown: %w", err)
+		return nil, err
 	}
-	logger := log.NewLogger(log.DefaultLevel)
-	ghrt := roundtripper.NewTransport(ctx, logger) /* This round tripper handles the access token. */
-	ghClient := github.NewClient(&http.Client{Transport: ghrt})
-	err = writeToComment(ctx, ghClient, ownerRepo[0], ownerRepo[1], report)
+	deps, err := dependencydiff.GetDependencyDiffResults(
+		ctx, cfg.repoURI, cfg.base, cfg.head, cfg.checks, changeTypeMap,
+	)
 	if err != nil {
-		return fmt.Errorf("error writting the report to comment: %w", err)
+		return nil, fmt.Errorf("error getting dependency-diff: %w", err)
+	}
+	requested := map[pkg.ChangeType]bool{}
+	for _, ct := range cfg.changeTypes {
+		requested[pkg.ChangeType(ct)] = true
 	}
+	deps = filterChangeTypes(deps, requested)
 
-	// Create a new check run and visualize dependency-diffs as check run annotations.
-	err = visualizeToCheckRun(ctx, ghClient, ownerRepo[0], ownerRepo[1], deps)
+	// Skip the allowlisted dependencies and find the denied ones and those with score exceptions.
+	deps, decisions := cfg.policy.apply(deps, time.Now())
+	violations, err := cfg.gate.evaluate(deps, decisions)
 	if err != nil {
-		return fmt.Errorf("error visualizing the results to check run: %w", err)
+		return nil, fmt.Errorf("error evaluating score thresholds: %w", err)
 	}
-	// TODO (#issue number): give the complete dependency-diff JSON results in the Action, at somewhere else.
-	return nil
+	return &runResults{deps: deps, decisions: decisions, violations: violations}, nil
 }
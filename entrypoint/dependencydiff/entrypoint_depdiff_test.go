@@ -0,0 +1,116 @@
+// Copyright 2022 Security Scorecard Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//nolint
+package dependencydiff
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ossf/scorecard/v4/pkg"
+)
+
+func Test_changeTypesToFetch(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name        string
+		changeTypes []string
+		want        map[pkg.ChangeType]bool
+		wantErr     error
+	}{
+		{
+			name:        "Added",
+			changeTypes: []string{"added"},
+			want:        map[pkg.ChangeType]bool{pkg.Added: true},
+		},
+		{
+			name:        "Updated",
+			changeTypes: []string{"updated"},
+			want:        map[pkg.ChangeType]bool{pkg.Added: true, pkg.Removed: true, pkg.Updated: true},
+		},
+		{
+			name:        "RemovedAndUpdated",
+			changeTypes: []string{"removed", "updated"},
+			want:        map[pkg.ChangeType]bool{pkg.Added: true, pkg.Removed: true, pkg.Updated: true},
+		},
+		{
+			name:        "Invalid",
+			changeTypes: []string{"renamed"},
+			wantErr:     errInvalid,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := changeTypesToFetch(tt.changeTypes)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("changeTypesToFetch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("changeTypesToFetch() (-want +got): %s", diff)
+			}
+		})
+	}
+}
+
+func Test_filterChangeTypes(t *testing.T) {
+	t.Parallel()
+	deps := []pkg.DependencyCheckResult{
+		testDependency("brand-new", pkg.Added, nil),
+		testDependency("lib", pkg.Added, nil),
+		testDependency("lib", pkg.Removed, nil),
+		testDependency("gone", pkg.Removed, nil),
+	}
+	tests := []struct {
+		name        string
+		changeTypes []pkg.ChangeType
+		want        []string
+	}{
+		{
+			name:        "Updated",
+			changeTypes: []pkg.ChangeType{pkg.Updated},
+			want:        []string{"lib added", "lib removed"},
+		},
+		{
+			name:        "Added",
+			changeTypes: []pkg.ChangeType{pkg.Added},
+			want:        []string{"brand-new added", "lib added"},
+		},
+		{
+			name:        "RemovedAndUpdated",
+			changeTypes: []pkg.ChangeType{pkg.Removed, pkg.Updated},
+			want:        []string{"lib added", "lib removed", "gone removed"},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			requested := map[pkg.ChangeType]bool{}
+			for _, ct := range tt.changeTypes {
+				requested[ct] = true
+			}
+			got := []string{}
+			for _, d := range filterChangeTypes(deps, requested) {
+				got = append(got, d.Name+" "+string(*d.ChangeType))
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("filterChangeTypes() (-want +got): %s", diff)
+			}
+		})
+	}
+}
@@ -19,4 +19,7 @@ import "errors"
 var (
 	errEmpty   = errors.New("empty")
 	errInvalid = errors.New("invalid")
+
+	errScoreThreshold = errors.New("dependencies below the score thresholds")
+	errSinks          = errors.New("errors occurred while writing the dependency-diff report")
 )
@@ -0,0 +1,191 @@
+// Copyright 2022 Security Scorecard Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dependencydiff
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ossf/scorecard/v4/checker"
+	"github.com/ossf/scorecard/v4/checks"
+	docs "github.com/ossf/scorecard/v4/docs/checks"
+	"github.com/ossf/scorecard/v4/pkg"
+)
+
+const (
+	// lowScoreThreshold is the check score below which a dependency is highlighted, unless the gate
+	// configures a threshold for the check.
+	lowScoreThreshold = 6
+	gateOperator      = ">="
+	// aggregateCheckName is used in gate violations tripped by the aggregate score rather than a single check.
+	aggregateCheckName = "Aggregate"
+)
+
+// scoreGate holds the minimum scores that added and updated dependencies must meet.
+type scoreGate struct {
+	minAggregate *float64
+	minChecks    map[string]int
+}
+
+// gateViolation records a dependency that fell below one of the gate thresholds.
+type gateViolation struct {
+	dependency string
+	version    string
+	check      string
+	note       string
+	score      float64
+	threshold  float64
+}
+
+// parseScoreGate parses the minimum aggregate score and the per-check minimum scores, the latter given in the
+// form of "Maintained>=5,Code-Review>=7". A nil gate is returned if neither is set.
+func parseScoreGate(minAggregate, minChecks string) (*scoreGate, error) {
+	minAggregate = strings.TrimSpace(minAggregate)
+	minChecks = strings.TrimSpace(minChecks)
+	if minAggregate == "" && minChecks == "" {
+		return nil, nil
+	}
+	gate := scoreGate{minChecks: map[string]int{}}
+	if minAggregate != "" {
+		score, err := strconv.ParseFloat(minAggregate, 64)
+		if err != nil || score < checker.MinResultScore || score > checker.MaxResultScore {
+			return nil, fmt.Errorf("%w: minimum aggregate score %s", errInvalid, minAggregate)
+		}
+		gate.minAggregate = &score
+	}
+	if minChecks == "" {
+		return &gate, nil
+	}
+	allChecks := checks.GetAll()
+	for _, entry := range strings.Split(minChecks, ",") {
+		name, threshold, found := strings.Cut(entry, gateOperator)
+		if !found {
+			return nil, fmt.Errorf("%w: check threshold %s", errInvalid, entry)
+		}
+		name = strings.TrimSpace(name)
+		if _, ok := allChecks[name]; !ok {
+			return nil, fmt.Errorf("%w: check name %s", errInvalid, name)
+		}
+		score, err := strconv.Atoi(strings.TrimSpace(threshold))
+		if err != nil || score < checker.MinResultScore || score > checker.MaxResultScore {
+			return nil, fmt.Errorf("%w: check threshold %s", errInvalid, entry)
+		}
+		gate.minChecks[name] = score
+	}
+	return &gate, nil
+}
+
+// checkThreshold returns the score below which a check is considered low for the dependencies.
+func (g *scoreGate) checkThreshold(name string) int {
+	if g != nil {
+		if threshold, ok := g.minChecks[name]; ok {
+			return threshold
+		}
+	}
+	return lowScoreThreshold
+}
+
+// evaluate returns the gate violations of the added and updated dependencies, after applying the policy
+// decisions. Dependencies without Scorecard results and inconclusive check scores can't be evaluated and
+// are skipped, but denied dependencies are always violations.
+func (g *scoreGate) evaluate(deps []pkg.DependencyCheckResult, decisions policyDecisions,
+) ([]gateViolation, error) {
+	violations := []gateViolation{}
+	doc, err := docs.Read()
+	if err != nil {
+		return nil, fmt.Errorf("error reading docs: %w", err)
+	}
+	for i := range deps {
+		d := deps[i]
+		if d.ChangeType == nil || *d.ChangeType == pkg.Removed {
+			continue
+		}
+		version := valueOrEmpty(d.Version)
+		decision := decisions[d.Name]
+		if decision.denied != nil {
+			violations = append(violations, gateViolation{
+				dependency: d.Name,
+				version:    version,
+				check:      denylistCheckName,
+				note:       decision.denied.Reason,
+			})
+		}
+		scResult := d.ScorecardResultWithError.ScorecardResult
+		if g == nil || scResult == nil {
+			continue
+		}
+		note := ""
+		if decision.expiredException != nil {
+			note = fmt.Sprintf("policy exception expired on %s", decision.expiredException.Expires)
+		}
+		if g.minAggregate != nil {
+			aggregate, err := scResult.GetAggregateScore(doc)
+			if err != nil {
+				return nil, fmt.Errorf("error getting the aggregate score: %w", err)
+			}
+			threshold, gated := decision.exception.threshold(aggregateCheckName, *g.minAggregate)
+			if gated && aggregate != checker.InconclusiveResultScore && aggregate < threshold {
+				violations = append(violations, gateViolation{
+					dependency: d.Name,
+					version:    version,
+					check:      aggregateCheckName,
+					score:      aggregate,
+					threshold:  threshold,
+					note:       note,
+				})
+			}
+		}
+		for _, c := range scResult.Checks {
+			minScore, ok := g.minChecks[c.Name]
+			if !ok || c.Score == checker.InconclusiveResultScore {
+				continue
+			}
+			threshold, gated := decision.exception.threshold(c.Name, float64(minScore))
+			if gated && float64(c.Score) < threshold {
+				violations = append(violations, gateViolation{
+					dependency: d.Name,
+					version:    version,
+					check:      c.Name,
+					score:      float64(c.Score),
+					threshold:  threshold,
+					note:       note,
+				})
+			}
+		}
+	}
+	return violations, nil
+}
+
+// violationsAsMarkdown lists the gate violations as a markdown table.
+func violationsAsMarkdown(violations []gateViolation) string {
+	if len(violations) == 0 {
+		return ""
+	}
+	result := ":x: **The following dependencies violate the configured score thresholds or dependency policy:**\n\n"
+	result += "| Dependency | Version | Check | Score | Threshold | Note |\n"
+	result += "| --- | --- | --- | --- | --- | --- |\n"
+	for _, v := range violations {
+		if v.check == denylistCheckName {
+			result += fmt.Sprintf("| %s | %s | %s | - | - | %s |\n", v.dependency, v.version, v.check, v.note)
+			continue
+		}
+		result += fmt.Sprintf(
+			"| %s | %s | %s | %.1f | %.1f | %s |\n",
+			v.dependency, v.version, v.check, v.score, v.threshold, v.note,
+		)
+	}
+	return result
+}
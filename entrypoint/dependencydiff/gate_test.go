@@ -0,0 +1,130 @@
+// Copyright 2022 Security Scorecard Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//nolint
+package dependencydiff
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ossf/scorecard/v4/checker"
+	"github.com/ossf/scorecard/v4/pkg"
+)
+
+func testDependency(name string, changeType pkg.ChangeType, scores map[string]int) pkg.DependencyCheckResult {
+	version := "v1.0.0"
+	d := pkg.DependencyCheckResult{
+		Name:       name,
+		Version:    &version,
+		ChangeType: &changeType,
+	}
+	if scores != nil {
+		result := &pkg.ScorecardResult{}
+		for _, check := range []string{"Code-Review", "Maintained"} {
+			if score, ok := scores[check]; ok {
+				result.Checks = append(result.Checks, checker.CheckResult{Name: check, Score: score})
+			}
+		}
+		d.ScorecardResultWithError.ScorecardResult = result
+	}
+	return d
+}
+
+func Test_parseScoreGate(t *testing.T) {
+	t.Parallel()
+	aggregate := 5.5
+	tests := []struct {
+		name         string
+		minAggregate string
+		minChecks    string
+		want         *scoreGate
+		wantErr      bool
+	}{
+		{
+			name: "NoGate",
+		},
+		{
+			name:         "AggregateAndChecks",
+			minAggregate: "5.5",
+			minChecks:    "Maintained>=5, Code-Review >= 7",
+			want: &scoreGate{
+				minAggregate: &aggregate,
+				minChecks:    map[string]int{"Maintained": 5, "Code-Review": 7},
+			},
+		},
+		{
+			name:      "FailureUnknownCheck",
+			minChecks: "Not-A-Check>=5",
+			wantErr:   true,
+		},
+		{
+			name:      "FailureMissingOperator",
+			minChecks: "Maintained=5",
+			wantErr:   true,
+		},
+		{
+			name:      "FailureScoreOutOfRange",
+			minChecks: "Maintained>=11",
+			wantErr:   true,
+		},
+		{
+			name:         "FailureAggregateNotANumber",
+			minAggregate: "high",
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := parseScoreGate(tt.minAggregate, tt.minChecks)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseScoreGate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !cmp.Equal(tt.want, got, cmp.AllowUnexported(scoreGate{})) {
+				t.Errorf("parseScoreGate(): -want, +got:\n%s", cmp.Diff(tt.want, got, cmp.AllowUnexported(scoreGate{})))
+			}
+		})
+	}
+}
+
+func Test_scoreGate_evaluate(t *testing.T) {
+	t.Parallel()
+	gate, err := parseScoreGate("6.2", "Maintained>=5,Code-Review>=7")
+	if err != nil {
+		t.Fatalf("parseScoreGate: %v", err)
+	}
+	deps := []pkg.DependencyCheckResult{
+		testDependency("good", pkg.Added, map[string]int{"Maintained": 10, "Code-Review": 8}),
+		testDependency("unmaintained", pkg.Added, map[string]int{"Maintained": 2, "Code-Review": 10}),
+		testDependency("unreviewed", pkg.Updated, map[string]int{"Maintained": 10, "Code-Review": 3}),
+		testDependency("inconclusive", pkg.Added, map[string]int{"Maintained": checker.InconclusiveResultScore}),
+		testDependency("removed", pkg.Removed, map[string]int{"Maintained": 0, "Code-Review": 0}),
+		testDependency("no-results", pkg.Added, nil),
+	}
+	got, err := gate.evaluate(deps, nil)
+	if err != nil {
+		t.Fatalf("evaluate: %v", err)
+	}
+	// Maintained and Code-Review are both high-risk checks, so the aggregate score is their average.
+	want := []gateViolation{
+		{dependency: "unmaintained", version: "v1.0.0", check: aggregateCheckName, score: 6, threshold: 6.2},
+		{dependency: "unmaintained", version: "v1.0.0", check: "Maintained", score: 2, threshold: 5},
+		{dependency: "unreviewed", version: "v1.0.0", check: "Code-Review", score: 3, threshold: 7},
+	}
+	if !cmp.Equal(want, got, cmp.AllowUnexported(gateViolation{})) {
+		t.Errorf("evaluate(): -want, +got:\n%s", cmp.Diff(want, got, cmp.AllowUnexported(gateViolation{})))
+	}
+}
@@ -16,8 +16,6 @@ package dependencydiff
 
 import (
 	"fmt"
-	"net/http"
-	"net/url"
 
 	docs "github.com/ossf/scorecard/v4/docs/checks"
 	"github.com/ossf/scorecard/v4/pkg"
@@ -79,35 +77,14 @@ func dependencySliceToMaps(deps []pkg.DependencyCheckResult) (map[string]pkg.Dep
 	for _, d := range deps {
 		if d.ChangeType != nil {
 			switch *d.ChangeType {
-			case pkg.Added:
+			case pkg.Added, pkg.Updated:
+				// The current data source GitHub Dependency Review won't give the updated dependencies,
+				// so they are found by pairing the added and removed ones, see findUpdates.
 				added[d.Name] = d
 			case pkg.Removed:
 				removed[d.Name] = d
-			case pkg.Updated:
-				// Do nothing, for now.
-				// The current data source GitHub Dependency Review won't give the updated dependencies,
-				// so we need to find them manually by checking the added/removed maps.
 			}
 		}
 	}
 	return added, removed
 }
-
-func entryExists(system, name, version string) (bool, error) {
-	url := fmt.Sprintf(
-		"https://deps.dev/_/s/%s/p/%s/v/%s",
-		url.PathEscape(system),
-		url.PathEscape(name),
-		url.PathEscape(version),
-	)
-	resp, err := http.Get(url)
-	if err != nil {
-		return false, fmt.Errorf("error requesting deps.dec/_: %w", err)
-	}
-	switch resp.StatusCode {
-	case http.StatusOK:
-		return true, nil
-	default:
-		return false, nil
-	}
-}
@@ -0,0 +1,121 @@
+// Copyright 2022 Security Scorecard Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dependencydiff
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// fallbackLine is used for the annotations when the declaration of a dependency can't be found.
+const fallbackLine = 1
+
+// findDependencyLine returns the 1-based line of the dependency declaration in the manifest at manifestPath,
+// relative to the workspace. It falls back to the first line if the manifest can't be read or the dependency
+// isn't found in it.
+func findDependencyLine(workspace, manifestPath, name, version string) int {
+	if manifestPath == "" || name == "" {
+		return fallbackLine
+	}
+	path := filepath.Join(workspace, filepath.FromSlash(manifestPath))
+	if rel, err := filepath.Rel(workspace, path); err != nil || strings.HasPrefix(rel, "..") {
+		// Don't read anything outside of the workspace.
+		return fallbackLine
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return fallbackLine
+	}
+	defer f.Close()
+
+	patterns := dependencyPatterns(filepath.Base(path), name)
+	firstMatch := 0
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if !matchesAny(patterns, text) {
+			continue
+		}
+		// Lock files may list several versions of the same package, prefer the line with the version.
+		if version == "" || strings.Contains(text, version) {
+			return line
+		}
+		if firstMatch == 0 {
+			firstMatch = line
+		}
+	}
+	if firstMatch != 0 {
+		return firstMatch
+	}
+	return fallbackLine
+}
+
+// dependencyPatterns returns the patterns matching a dependency declaration for the given manifest file.
+func dependencyPatterns(manifest, name string) []*regexp.Regexp {
+	quoted := regexp.QuoteMeta(name)
+	switch manifest {
+	case "go.mod", "go.sum":
+		// `require example.com/mod v1.2.3` or a `example.com/mod v1.2.3` line in a require block.
+		return compile(`(^|\s)` + quoted + `\s+v`)
+	case "package.json":
+		return compile(`"` + quoted + `"\s*:`)
+	case "package-lock.json", "npm-shrinkwrap.json":
+		return compile(`"(node_modules/)?` + quoted + `"\s*:`)
+	case "yarn.lock", "pnpm-lock.yaml":
+		return compile(`^\s*"?/?` + quoted + `[@/]`)
+	case "requirements.txt", "Pipfile":
+		// Python package names are case-insensitive, and '-', '_' and '.' are interchangeable.
+		normalized := regexp.MustCompile(`[-_.]+`).ReplaceAllString(strings.ToLower(name), "-")
+		pythonName := strings.ReplaceAll(regexp.QuoteMeta(normalized), "-", `[-_.]+`)
+		return compile(`(?i)^\s*"?` + pythonName + `"?\s*($|[=<>~!;\[ ])`)
+	case "pom.xml":
+		// Maven dependencies are named `groupId:artifactId`, the artifactId is on its own line.
+		artifact := name
+		if i := strings.LastIndex(name, ":"); i >= 0 {
+			artifact = name[i+1:]
+		}
+		return compile(`<artifactId>\s*` + regexp.QuoteMeta(artifact) + `\s*</artifactId>`)
+	case "Cargo.toml":
+		return compile(`^\s*"?` + quoted + `"?\s*=`)
+	case "Gemfile", "gems.rb":
+		return compile(`^\s*gem\s+['"]` + quoted + `['"]`)
+	case "Gemfile.lock", "gems.locked":
+		return compile(`^\s+` + quoted + ` \(`)
+	case "Cargo.lock", "poetry.lock":
+		return compile(`^name\s*=\s*"` + quoted + `"`)
+	}
+	// Other manifests and lock files: the name as a standalone token.
+	return compile(`(^|[\s"'/])` + quoted + `([\s"'@:=]|$)`)
+}
+
+func compile(exprs ...string) []*regexp.Regexp {
+	patterns := []*regexp.Regexp{}
+	for _, e := range exprs {
+		patterns = append(patterns, regexp.MustCompile(e))
+	}
+	return patterns
+}
+
+func matchesAny(patterns []*regexp.Regexp, text string) bool {
+	for _, p := range patterns {
+		if p.MatchString(text) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+// Copyright 2022 Security Scorecard Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//nolint
+package dependencydiff
+
+import (
+	"testing"
+)
+
+const testManifestsDir = "testdata/manifests"
+
+func Test_findDependencyLine(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		manifest string
+		dep      string
+		version  string
+		want     int
+	}{
+		{name: "GoModRequire", manifest: "go.mod", dep: "github.com/spf13/cobra", version: "v1.5.0", want: 5},
+		{name: "GoModRequireBlock", manifest: "go.mod", dep: "github.com/google/go-github/v45", want: 9},
+		{name: "PackageJSON", manifest: "package.json", dep: "left-pad", version: "1.3.0", want: 5},
+		{name: "PackageJSONScoped", manifest: "package.json", dep: "@types/node", want: 6},
+		{name: "Requirements", manifest: "requirements.txt", dep: "requests", version: "2.28.1", want: 2},
+		{name: "RequirementsNormalizedName", manifest: "requirements.txt", dep: "typing-extensions", want: 3},
+		{name: "RequirementsUnpinned", manifest: "requirements.txt", dep: "urllib3", want: 4},
+		{name: "PomArtifact", manifest: "pom.xml", dep: "com.google.guava:guava", version: "31.1-jre", want: 10},
+		{name: "CargoToml", manifest: "Cargo.toml", dep: "tokio", want: 7},
+		{name: "Gemfile", manifest: "Gemfile", dep: "rack", want: 4},
+		{name: "YarnLockPrefersVersion", manifest: "yarn.lock", dep: "left-pad", version: "1.3.0", want: 6},
+		{name: "PoetryLock", manifest: "poetry.lock", dep: "requests", want: 6},
+		{name: "NotFound", manifest: "go.mod", dep: "example.com/missing", want: fallbackLine},
+		{name: "MissingManifest", manifest: "build.gradle", dep: "junit", want: fallbackLine},
+		{name: "OutsideWorkspace", manifest: "../../gate.go", dep: "fmt", want: fallbackLine},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := findDependencyLine(testManifestsDir, tt.manifest, tt.dep, tt.version)
+			if got != tt.want {
+				t.Errorf("findDependencyLine() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+// Copyright 2022 Security Scorecard Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dependencydiff
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/ossf/scorecard/v4/checker"
+	"github.com/ossf/scorecard/v4/pkg"
+)
+
+const (
+	// maxCommentLength is the size limit of a GitHub comment body.
+	maxCommentLength = 65536
+	// omittedNoticeLength is the room kept for the notice on the left out dependency-diffs.
+	omittedNoticeLength  = 1024
+	riskiestChangesCount = 10
+	unknownEcosystem     = "other"
+)
+
+// markdownEntry is a rendered dependency-diff in the markdown report.
+type markdownEntry struct {
+	ecosystem string
+	text      string
+}
+
+// riskyChange is a row of the riskiest changes table.
+type riskyChange struct {
+	name      string
+	version   string
+	ecosystem string
+	change    string
+	score     string
+	rank      float64
+	denied    bool
+	result    *pkg.ScorecardResult
+}
+
+func newRiskyChange(d *pkg.DependencyCheckResult, aggregate float64, updates map[string]dependencyUpdate,
+	decisions policyDecisions,
+) riskyChange {
+	r := riskyChange{
+		name:      d.Name,
+		version:   valueOrEmpty(d.Version),
+		ecosystem: valueOrEmpty(d.Ecosystem),
+		change:    string(pkg.Added),
+		score:     scoreCell(aggregate),
+		rank:      aggregate,
+		denied:    decisions[d.Name].denied != nil,
+		result:    d.ScorecardResultWithError.ScorecardResult,
+	}
+	if r.rank == checker.InconclusiveResultScore {
+		// Dependencies without a score can't be vouched for, so they rank with the riskiest ones.
+		r.rank = negInf
+	}
+	if u, ok := updates[d.Name]; ok {
+		r.change = string(pkg.Updated)
+		if u.oldResult != nil {
+			if u.oldResult.Version != nil {
+				r.version = fmt.Sprintf("%s → %s", *u.oldResult.Version, r.version)
+			}
+			r.score = fmt.Sprintf("%s → %s", scoreCell(u.oldAggregate), r.score)
+		}
+		if len(u.warnings()) > 0 {
+			r.change += " :warning:"
+		}
+	}
+	if r.denied {
+		r.change += " :no_entry:"
+	}
+	return r
+}
+
+func scoreCell(score float64) string {
+	if score == negInf || score == checker.InconclusiveResultScore {
+		return "n/a"
+	}
+	return fmt.Sprintf("%.1f", score)
+}
+
+// sortByRisk sorts the changes with the denied ones first, then by their aggregate scores in ascending order.
+func sortByRisk(risks []riskyChange) {
+	sort.SliceStable(risks, func(i, j int) bool {
+		if risks[i].denied != risks[j].denied {
+			return risks[i].denied
+		}
+		return risks[i].rank < risks[j].rank
+	})
+}
+
+// riskiestChangesAsMarkdown renders a table of the riskiest changes, which are expected to be sorted by sortByRisk.
+func riskiestChangesAsMarkdown(risks []riskyChange) string {
+	if len(risks) == 0 {
+		return ""
+	}
+	result := "### Riskiest changes\n\n"
+	if len(risks) > riskiestChangesCount {
+		result += fmt.Sprintf(
+			"The %d riskiest of %d added or updated dependencies:\n\n", riskiestChangesCount, len(risks),
+		)
+		risks = risks[:riskiestChangesCount]
+	}
+	result += "| Dependency | Ecosystem | Change | Score |\n"
+	result += "| --- | --- | --- | --- |\n"
+	for _, r := range risks {
+		name := "`" + r.name + "`"
+		if r.version != "" {
+			name += " @ " + r.version
+		}
+		result += fmt.Sprintf("| %s | %s | %s | %s |\n", name, r.ecosystem, r.change, r.score)
+	}
+	return result + "\n"
+}
+
+// ecosystemSectionsAsMarkdown renders the dependency-diffs in a collapsible section per ecosystem,
+// within budget bytes. It returns the number of dependency-diffs left out to stay within the budget.
+func ecosystemSectionsAsMarkdown(entries []markdownEntry, budget int) (string, int) {
+	byEcosystem := map[string][]markdownEntry{}
+	ecosystems := []string{}
+	for _, e := range entries {
+		ecosystem := e.ecosystem
+		if ecosystem == "" {
+			ecosystem = unknownEcosystem
+		}
+		if _, ok := byEcosystem[ecosystem]; !ok {
+			ecosystems = append(ecosystems, ecosystem)
+		}
+		byEcosystem[ecosystem] = append(byEcosystem[ecosystem], e)
+	}
+	sort.Strings(ecosystems)
+
+	// Small reports are expanded, since the sections would hide nearly everything otherwise.
+	open := ""
+	if len(entries) <= riskiestChangesCount {
+		open = " open"
+	}
+	const closing = "</details>\n\n"
+	result, written := "", 0
+	for _, ecosystem := range ecosystems {
+		section := fmt.Sprintf(
+			"<details%s>\n<summary><b>%s</b> (%d)</summary>\n\n",
+			open, ecosystem, len(byEcosystem[ecosystem]),
+		)
+		sectionWritten := 0
+		for _, e := range byEcosystem[ecosystem] {
+			text := e.text + "\n\n"
+			if len(result)+len(section)+len(text)+len(closing) > budget {
+				break
+			}
+			section += text
+			sectionWritten++
+		}
+		if sectionWritten == 0 {
+			break
+		}
+		result += section + closing
+		written += sectionWritten
+		if sectionWritten < len(byEcosystem[ecosystem]) {
+			break
+		}
+	}
+	return result, len(entries) - written
+}
+
+// omittedNotice tells the dependency-diffs were left out of the report and where to find the complete results.
+func omittedNotice(omitted int, resultsFile string) string {
+	result := fmt.Sprintf(
+		"> :scissors: %d dependency-diffs were left out to keep this report within the GitHub comment size limit.",
+		omitted,
+	)
+	if resultsFile != "" {
+		result += fmt.Sprintf(" The complete results are in `%s` in the workflow workspace.", resultsFile)
+	} else {
+		result += " Set the `dependency_diff_results_file` input to get the complete results as a JSON file."
+	}
+	return result + "\n\n"
+}
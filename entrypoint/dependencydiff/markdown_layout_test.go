@@ -0,0 +1,162 @@
+// Copyright 2022 Security Scorecard Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//nolint
+package dependencydiff
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ossf/scorecard/v4/pkg"
+)
+
+func Test_riskiestChangesAsMarkdown(t *testing.T) {
+	t.Parallel()
+	risks := []riskyChange{
+		{name: "safe", change: "added", score: "9.0", rank: 9},
+		{name: "risky", change: "added", score: "2.0", rank: 2},
+		{name: "unscored", change: "added", score: "n/a", rank: negInf},
+		{name: "denied", change: "added :no_entry:", score: "9.5", rank: 9.5, denied: true},
+	}
+	sortByRisk(risks)
+	got := riskiestChangesAsMarkdown(risks)
+	want := []string{"`denied`", "`unscored`", "`risky`", "`safe`"}
+	order := []string{}
+	for _, line := range strings.Split(got, "\n") {
+		for _, name := range want {
+			if strings.HasPrefix(line, "| "+name) {
+				order = append(order, name)
+			}
+		}
+	}
+	if !cmp.Equal(want, order) {
+		t.Errorf("rows: -want, +got:\n%s", cmp.Diff(want, order))
+	}
+}
+
+func Test_ecosystemSectionsAsMarkdown(t *testing.T) {
+	t.Parallel()
+	entries := []markdownEntry{
+		{ecosystem: "npm", text: "npm-a"},
+		{ecosystem: "gomod", text: "go-a"},
+		{ecosystem: "npm", text: "npm-b"},
+		{text: "unknown-a"},
+	}
+	tests := []struct {
+		name        string
+		budget      int
+		wantOmitted int
+		wantTexts   []string
+	}{
+		{
+			name:      "WithinBudget",
+			budget:    maxCommentLength,
+			wantTexts: []string{"go-a", "npm-a", "npm-b", "unknown-a"},
+		},
+		{
+			name:        "OverBudget",
+			budget:      140,
+			wantOmitted: 2,
+			wantTexts:   []string{"go-a", "npm-a"},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, omitted := ecosystemSectionsAsMarkdown(entries, tt.budget)
+			if len(got) > tt.budget {
+				t.Errorf("got %d bytes, want at most %d", len(got), tt.budget)
+			}
+			if omitted != tt.wantOmitted {
+				t.Errorf("omitted = %d, want %d", omitted, tt.wantOmitted)
+			}
+			texts := []string{}
+			for _, line := range strings.Split(got, "\n") {
+				if strings.Contains(line, "-") && !strings.HasPrefix(line, "<") {
+					texts = append(texts, line)
+				}
+			}
+			if !cmp.Equal(tt.wantTexts, texts) {
+				t.Errorf("-want, +got:\n%s", cmp.Diff(tt.wantTexts, texts))
+			}
+		})
+	}
+}
+
+func Test_dependencydiffResultsAsMarkdown_bounded(t *testing.T) {
+	t.Parallel()
+	deps := []pkg.DependencyCheckResult{}
+	for i := 0; i < 2000; i++ {
+		name := fmt.Sprintf("github.com/some-rather-long-owner-name/some-rather-long-package-name-%d", i)
+		deps = append(deps, testDependency(name, pkg.Added, map[string]int{"Maintained": i % 11}))
+	}
+	report, err := dependencydiffResultsAsMarkdown(context.Background(), deps, &markdownOptions{
+		base:        "main",
+		head:        "feature",
+		resultsFile: "depdiff.json",
+	})
+	if err != nil {
+		t.Fatalf("dependencydiffResultsAsMarkdown: %v", err)
+	}
+	if len(*report) > maxCommentLength {
+		t.Errorf("got %d bytes, want at most %d", len(*report), maxCommentLength)
+	}
+	for _, want := range []string{reportMarker, "### Riskiest changes", "were left out", "`depdiff.json`"} {
+		if !strings.Contains(*report, want) {
+			t.Errorf("report has no %q", want)
+		}
+	}
+}
+
+func Test_dependencydiffResultsAsMarkdown_depsDevTags(t *testing.T) {
+	t.Parallel()
+	fake, client := newTestDepsDev(t, 0, "")
+	fake.known["/_/s/go/p/github.com/owner/updated/v/v1.0.0"] = true
+	goDependency := func(name string, changeType pkg.ChangeType, version string) pkg.DependencyCheckResult {
+		d := testDependency(name, changeType, map[string]int{"Maintained": 5})
+		ecosystem := "go"
+		d.Ecosystem, d.Version = &ecosystem, &version
+		return d
+	}
+	deps := []pkg.DependencyCheckResult{
+		goDependency("github.com/owner/lib", pkg.Added, "v1.0.0"),
+		goDependency("github.com/owner/updated", pkg.Removed, "v0.9.0"),
+		goDependency("github.com/owner/updated", pkg.Added, "v1.0.0"),
+	}
+	report, err := dependencydiffResultsAsMarkdown(context.Background(), deps, &markdownOptions{
+		depsDev:        client,
+		depsDevBaseURL: "https://deps.example.com/",
+		base:           "main",
+		head:           "feature",
+	})
+	if err != nil {
+		t.Fatalf("dependencydiffResultsAsMarkdown: %v", err)
+	}
+	for _, want := range []string{
+		"**`added`**  **`[deps.dev](https://deps.example.com/go/github.com%2Fowner%2Flib)`**",
+		"**`updated`**  **`[deps.dev](https://deps.example.com/go/github.com%2Fowner%2Fupdated)`**",
+	} {
+		if !strings.Contains(*report, want) {
+			t.Errorf("report has no %q", want)
+		}
+	}
+	if strings.Contains(*report, "(https://deps.dev/go/") {
+		t.Errorf("report links to deps.dev instead of the configured instance")
+	}
+}
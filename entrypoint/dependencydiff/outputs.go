@@ -0,0 +1,66 @@
+// Copyright 2022 Security Scorecard Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dependencydiff
+
+import (
+	"fmt"
+
+	"github.com/ossf/scorecard/v4/checker"
+	docs "github.com/ossf/scorecard/v4/docs/checks"
+	"github.com/ossf/scorecard/v4/pkg"
+)
+
+// dependencydiffOutputs returns the action outputs for the dependency-diff results.
+func dependencydiffOutputs(deps []pkg.DependencyCheckResult) (map[string]string, error) {
+	lowest, err := lowestAggregateScore(deps)
+	if err != nil {
+		return nil, err
+	}
+	outputs := map[string]string{
+		// Left empty if no added or updated dependency has a score.
+		"dependency_diff_lowest_score": "",
+	}
+	if lowest != checker.InconclusiveResultScore {
+		outputs["dependency_diff_lowest_score"] = fmt.Sprintf("%.1f", lowest)
+	}
+	return outputs, nil
+}
+
+// lowestAggregateScore returns the lowest aggregate score of the added and updated dependencies,
+// or checker.InconclusiveResultScore if none of them has a score.
+func lowestAggregateScore(deps []pkg.DependencyCheckResult) (float64, error) {
+	doc, err := docs.Read()
+	if err != nil {
+		return 0, fmt.Errorf("error reading docs: %w", err)
+	}
+	lowest := float64(checker.InconclusiveResultScore)
+	for i := range deps {
+		d := deps[i]
+		if d.ChangeType == nil || *d.ChangeType == pkg.Removed {
+			continue
+		}
+		score, err := aggregateScore(&d, doc)
+		if err != nil {
+			return 0, err
+		}
+		if score == checker.InconclusiveResultScore {
+			continue
+		}
+		if lowest == checker.InconclusiveResultScore || score < lowest {
+			lowest = score
+		}
+	}
+	return lowest, nil
+}
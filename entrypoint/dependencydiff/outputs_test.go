@@ -0,0 +1,60 @@
+// Copyright 2022 Security Scorecard Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//nolint
+package dependencydiff
+
+import (
+	"testing"
+
+	"github.com/ossf/scorecard/v4/pkg"
+)
+
+func Test_dependencydiffOutputs(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		deps []pkg.DependencyCheckResult
+		want string
+	}{
+		{
+			name: "LowestAddedScore",
+			deps: []pkg.DependencyCheckResult{
+				testDependency("high", pkg.Added, map[string]int{"Maintained": 9}),
+				testDependency("low", pkg.Added, map[string]int{"Maintained": 3}),
+				testDependency("removed", pkg.Removed, map[string]int{"Maintained": 0}),
+				testDependency("unscored", pkg.Added, nil),
+			},
+			want: "3.0",
+		},
+		{
+			name: "NoScores",
+			deps: []pkg.DependencyCheckResult{testDependency("unscored", pkg.Added, nil)},
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := dependencydiffOutputs(tt.deps)
+			if err != nil {
+				t.Fatalf("dependencydiffOutputs: %v", err)
+			}
+			if got["dependency_diff_lowest_score"] != tt.want {
+				t.Errorf("dependency_diff_lowest_score = %q, want %q", got["dependency_diff_lowest_score"], tt.want)
+			}
+		})
+	}
+}
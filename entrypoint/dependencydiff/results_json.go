@@ -0,0 +1,109 @@
+// Copyright 2022 Security Scorecard Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dependencydiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/ossf/scorecard/v4/checker"
+	docs "github.com/ossf/scorecard/v4/docs/checks"
+	"github.com/ossf/scorecard/v4/pkg"
+)
+
+// jsonResultsVersion is the version of the JSON results document. Bump it on breaking changes to the format.
+const jsonResultsVersion = 1
+
+type jsonCheck struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+	Score  int    `json:"score"`
+}
+
+type jsonDependency struct {
+	Name             string      `json:"name"`
+	ChangeType       string      `json:"changeType"`
+	Ecosystem        *string     `json:"ecosystem,omitempty"`
+	Version          *string     `json:"version,omitempty"`
+	PackageURL       *string     `json:"packageUrl,omitempty"`
+	SourceRepository *string     `json:"sourceRepository,omitempty"`
+	ManifestPath     *string     `json:"manifestPath,omitempty"`
+	AggregateScore   *float64    `json:"aggregateScore,omitempty"`
+	Checks           []jsonCheck `json:"checks,omitempty"`
+	Error            string      `json:"error,omitempty"`
+}
+
+// jsonDependencyDiffResults is the machine-readable document of the dependency-diff results.
+type jsonDependencyDiffResults struct {
+	Base         string           `json:"base"`
+	Head         string           `json:"head"`
+	Dependencies []jsonDependency `json:"dependencies"`
+	Version      int              `json:"version"`
+}
+
+// dependencydiffResultsAsJSON writes the dependency-diff results to the writer as a versioned JSON document.
+func dependencydiffResultsAsJSON(deps []pkg.DependencyCheckResult, base, head string, writer io.Writer) error {
+	doc, err := docs.Read()
+	if err != nil {
+		return fmt.Errorf("error reading docs: %w", err)
+	}
+	out := jsonDependencyDiffResults{
+		Version:      jsonResultsVersion,
+		Base:         base,
+		Head:         head,
+		Dependencies: []jsonDependency{},
+	}
+	for i := range deps {
+		d := deps[i]
+		jd := jsonDependency{
+			Name:             d.Name,
+			Ecosystem:        d.Ecosystem,
+			Version:          d.Version,
+			PackageURL:       d.PackageURL,
+			SourceRepository: d.SourceRepository,
+			ManifestPath:     d.ManifestPath,
+		}
+		if d.ChangeType != nil {
+			jd.ChangeType = string(*d.ChangeType)
+		}
+		if d.ScorecardResultWithError.Error != nil {
+			jd.Error = d.ScorecardResultWithError.Error.Error()
+		}
+		if scResult := d.ScorecardResultWithError.ScorecardResult; scResult != nil {
+			aggregate, err := scResult.GetAggregateScore(doc)
+			if err != nil {
+				return fmt.Errorf("error getting the aggregate score: %w", err)
+			}
+			if aggregate != checker.InconclusiveResultScore {
+				jd.AggregateScore = &aggregate
+			}
+			for _, c := range scResult.Checks {
+				jd.Checks = append(jd.Checks, jsonCheck{
+					Name:   c.Name,
+					Score:  c.Score,
+					Reason: c.Reason,
+				})
+			}
+		}
+		out.Dependencies = append(out.Dependencies, jd)
+	}
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(out); err != nil {
+		return fmt.Errorf("error encoding results as json: %w", err)
+	}
+	return nil
+}
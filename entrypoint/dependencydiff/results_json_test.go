@@ -0,0 +1,67 @@
+// Copyright 2022 Security Scorecard Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//nolint
+package dependencydiff
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ossf/scorecard/v4/pkg"
+)
+
+func Test_dependencydiffResultsAsJSON(t *testing.T) {
+	t.Parallel()
+	deps := []pkg.DependencyCheckResult{
+		testDependency("added", pkg.Added, map[string]int{"Maintained": 10, "Code-Review": 6}),
+		testDependency("removed", pkg.Removed, nil),
+	}
+	var buf bytes.Buffer
+	if err := dependencydiffResultsAsJSON(deps, "main", "feature", &buf); err != nil {
+		t.Fatalf("dependencydiffResultsAsJSON: %v", err)
+	}
+	got := jsonDependencyDiffResults{}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("json.Unmarshal: %v", err)
+	}
+	version, aggregate := "v1.0.0", 8.0
+	want := jsonDependencyDiffResults{
+		Version: jsonResultsVersion,
+		Base:    "main",
+		Head:    "feature",
+		Dependencies: []jsonDependency{
+			{
+				Name:           "added",
+				ChangeType:     "added",
+				Version:        &version,
+				AggregateScore: &aggregate,
+				Checks: []jsonCheck{
+					{Name: "Code-Review", Score: 6},
+					{Name: "Maintained", Score: 10},
+				},
+			},
+			{
+				Name:       "removed",
+				ChangeType: "removed",
+				Version:    &version,
+			},
+		},
+	}
+	if !cmp.Equal(want, got) {
+		t.Errorf("dependencydiffResultsAsJSON(): -want, +got:\n%s", cmp.Diff(want, got))
+	}
+}
@@ -0,0 +1,262 @@
+// Copyright 2022 Security Scorecard Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dependencydiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/ossf/scorecard/v4/checker"
+	docs "github.com/ossf/scorecard/v4/docs/checks"
+	"github.com/ossf/scorecard/v4/pkg"
+)
+
+const (
+	sarifSchema  = "https://raw.githubusercontent.com/oasis-tcs/sarif-spec/master/Schemata/sarif-schema-2.1.0.json"
+	sarifVersion = "2.1.0"
+	// sarifCategory keeps the dependency-diff alerts apart from the repository's own Scorecard alerts.
+	sarifCategory = "scorecard-dependency-diff"
+)
+
+type sarifText struct {
+	Text string `json:"text"`
+}
+
+type sarifHelp struct {
+	Text     string `json:"text"`
+	Markdown string `json:"markdown"`
+}
+
+type sarifRuleProperties struct {
+	Precision       string   `json:"precision"`
+	ProblemSeverity string   `json:"problem.severity"`
+	SeverityLevel   string   `json:"security-severity"`
+	Tags            []string `json:"tags"`
+}
+
+type sarifRule struct {
+	ID         string              `json:"id"`
+	Name       string              `json:"name"`
+	HelpURI    string              `json:"helpUri"`
+	ShortDesc  sarifText           `json:"shortDescription"`
+	FullDesc   sarifText           `json:"fullDescription"`
+	Help       sarifHelp           `json:"help"`
+	Properties sarifRuleProperties `json:"properties"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+	EndLine   int `json:"endLine"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifResult struct {
+	RuleID              string            `json:"ruleId"`
+	Level               string            `json:"level"`
+	Message             sarifText         `json:"message"`
+	Locations           []sarifLocation   `json:"locations"`
+	PartialFingerprints map[string]string `json:"partialFingerprints"`
+	RuleIndex           int               `json:"ruleIndex"`
+}
+
+type sarifAutomationDetails struct {
+	ID string `json:"id"`
+}
+
+type sarifRunProperties struct {
+	// Head is the head ref of the dependency-diff, which can't go in the automation details ID.
+	Head string `json:"head"`
+}
+
+type sarifRun struct {
+	AutomationDetails sarifAutomationDetails `json:"automationDetails"`
+	Tool              sarifTool              `json:"tool"`
+	Properties        sarifRunProperties     `json:"properties"`
+	// This MUST never be omitted or set as `nil`.
+	Results []sarifResult `json:"results"`
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+// dependencydiffResultsAsSARIF writes the low-scoring added and updated dependencies to the writer as a
+// SARIF 2.1.0 log, with one rule per Scorecard check and one result per dependency and check.
+func dependencydiffResultsAsSARIF(deps []pkg.DependencyCheckResult, gate *scoreGate, head, workspace string,
+	writer io.Writer,
+) error {
+	doc, err := docs.Read()
+	if err != nil {
+		return fmt.Errorf("error reading docs: %w", err)
+	}
+	run := sarifRun{
+		// Code scanning takes what comes before the last "/" of the ID as the category, so the head, e.g.
+		// a `user/feature` branch, would change the category and the alerts of other heads would be closed.
+		AutomationDetails: sarifAutomationDetails{ID: sarifCategory + "/"},
+		Tool: sarifTool{
+			Driver: sarifDriver{
+				Name:           "Scorecard Dependency-diff",
+				InformationURI: "https://github.com/ossf/scorecard-action",
+				Rules:          []sarifRule{},
+			},
+		},
+		Properties: sarifRunProperties{Head: head},
+		Results:    []sarifResult{},
+	}
+	ruleIndexes := map[string]int{}
+	for i := range deps {
+		d := deps[i]
+		scResult := d.ScorecardResultWithError.ScorecardResult
+		if d.ChangeType == nil || *d.ChangeType == pkg.Removed || scResult == nil {
+			continue
+		}
+		manifest, version := valueOrEmpty(d.ManifestPath), valueOrEmpty(d.Version)
+		line := findDependencyLine(workspace, manifest, d.Name, version)
+		for _, c := range scResult.Checks {
+			if c.Score == checker.InconclusiveResultScore || c.Score >= gate.checkThreshold(c.Name) {
+				continue
+			}
+			checkDoc, err := doc.GetCheck(c.Name)
+			if err != nil {
+				return fmt.Errorf("error getting the check doc: %w", err)
+			}
+			index, ok := ruleIndexes[c.Name]
+			if !ok {
+				index = len(run.Tool.Driver.Rules)
+				ruleIndexes[c.Name] = index
+				run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRuleForCheck(checkDoc))
+			}
+			run.Results = append(run.Results, sarifResult{
+				RuleID:    sarifRuleID(c.Name),
+				RuleIndex: index,
+				Level:     sarifLevel(checkDoc.GetRisk()),
+				Message: sarifText{
+					Text: fmt.Sprintf(
+						"%s dependency %s @ %s scored %d on %s: %s",
+						*d.ChangeType, d.Name, version, c.Score, c.Name, c.Reason,
+					),
+				},
+				Locations: []sarifLocation{
+					{
+						PhysicalLocation: sarifPhysicalLocation{
+							ArtifactLocation: sarifArtifactLocation{URI: manifest},
+							Region:           sarifRegion{StartLine: line, EndLine: line},
+						},
+					},
+				},
+				// Keep the alert stable across runs for the same dependency and check.
+				PartialFingerprints: map[string]string{
+					"scorecardDependency/v1": fmt.Sprintf("%s:%s:%s", d.Name, version, c.Name),
+				},
+			})
+		}
+	}
+	sort.SliceStable(run.Results, func(i, j int) bool { return run.Results[i].RuleIndex < run.Results[j].RuleIndex })
+	out := sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs:    []sarifRun{run},
+	}
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(out); err != nil {
+		return fmt.Errorf("error encoding results as sarif: %w", err)
+	}
+	return nil
+}
+
+func sarifRuleForCheck(checkDoc docs.CheckDoc) sarifRule {
+	remediation := ""
+	for _, r := range checkDoc.GetRemediation() {
+		remediation += fmt.Sprintf("- %s\n", r)
+	}
+	return sarifRule{
+		ID:        sarifRuleID(checkDoc.GetName()),
+		Name:      checkDoc.GetName(),
+		HelpURI:   checkDoc.GetDocumentationURL(""),
+		ShortDesc: sarifText{Text: fmt.Sprintf("Dependency with a low %s score", checkDoc.GetName())},
+		FullDesc:  sarifText{Text: checkDoc.GetShort()},
+		Help: sarifHelp{
+			Text: checkDoc.GetShort(),
+			Markdown: fmt.Sprintf(
+				"**Severity**: %s\n\n**Details**:\n%s\n\n**Remediation for the dependency's maintainers**:\n%s",
+				checkDoc.GetRisk(), checkDoc.GetDescription(), remediation,
+			),
+		},
+		Properties: sarifRuleProperties{
+			Precision:       "high",
+			ProblemSeverity: sarifLevel(checkDoc.GetRisk()),
+			SeverityLevel:   sarifSecuritySeverity(checkDoc.GetRisk()),
+			Tags:            append([]string{"supply-chain", "dependency"}, checkDoc.GetTags()...),
+		},
+	}
+}
+
+func sarifRuleID(checkName string) string {
+	// Identifier must be in Pascal case, and must not clash with the Scorecard rule IDs.
+	return fmt.Sprintf("Dependency%sID", strings.ReplaceAll(checkName, "-", ""))
+}
+
+func sarifLevel(risk string) string {
+	switch risk {
+	case "Critical", "High":
+		return "error"
+	case "Medium":
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+func sarifSecuritySeverity(risk string) string {
+	// https://docs.github.com/en/code-security/code-scanning/integrating-with-code-scanning/sarif-support-for-code-scanning#reportingdescriptor-object.
+	switch risk {
+	case "Critical":
+		return "9.0"
+	case "High":
+		return "7.0"
+	case "Medium":
+		return "4.0"
+	default:
+		return "1.0"
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2022 Security Scorecard Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//nolint
+package dependencydiff
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ossf/scorecard/v4/pkg"
+)
+
+func Test_dependencydiffResultsAsSARIF(t *testing.T) {
+	t.Parallel()
+	manifest := "go.mod"
+	added := testDependency("github.com/spf13/cobra", pkg.Added, map[string]int{"Maintained": 2, "Code-Review": 6})
+	added.ManifestPath = &manifest
+	deps := []pkg.DependencyCheckResult{
+		added,
+		testDependency("removed", pkg.Removed, map[string]int{"Maintained": 0}),
+	}
+	var buf bytes.Buffer
+	if err := dependencydiffResultsAsSARIF(deps, nil, "user/feature", testManifestsDir, &buf); err != nil {
+		t.Fatalf("dependencydiffResultsAsSARIF: %v", err)
+	}
+	got := sarifLog{}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("json.Unmarshal: %v", err)
+	}
+	if got.Version != sarifVersion || len(got.Runs) != 1 {
+		t.Fatalf("unexpected sarif log: version %s, %d runs", got.Version, len(got.Runs))
+	}
+	run := got.Runs[0]
+	if want := sarifCategory + "/"; run.AutomationDetails.ID != want {
+		t.Errorf("automation details ID = %q, want %q", run.AutomationDetails.ID, want)
+	}
+	if run.Properties.Head != "user/feature" {
+		t.Errorf("head = %q, want %q", run.Properties.Head, "user/feature")
+	}
+	gotRules := []string{}
+	for _, r := range run.Tool.Driver.Rules {
+		gotRules = append(gotRules, r.ID)
+	}
+	// Code-Review scored 6, which isn't below the default threshold.
+	if want := []string{"DependencyMaintainedID"}; !cmp.Equal(want, gotRules) {
+		t.Errorf("rules: -want, +got:\n%s", cmp.Diff(want, gotRules))
+	}
+	wantLocations := []sarifLocation{
+		{
+			PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: manifest},
+				Region:           sarifRegion{StartLine: 5, EndLine: 5},
+			},
+		},
+	}
+	if len(run.Results) != 1 {
+		t.Fatalf("got %d results, want 1", len(run.Results))
+	}
+	if !cmp.Equal(wantLocations, run.Results[0].Locations) {
+		t.Errorf("locations: -want, +got:\n%s", cmp.Diff(wantLocations, run.Results[0].Locations))
+	}
+}
@@ -0,0 +1,291 @@
+// Copyright 2022 Security Scorecard Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dependencydiff
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v45/github"
+	"github.com/ossf/scorecard/v4/pkg"
+)
+
+// Sink names, as given in the sinks input.
+const (
+	sinkComment     = "comment"
+	sinkCheckRun    = "check-run"
+	sinkStepSummary = "step-summary"
+	sinkFile        = "file"
+	sinkWebhook     = "webhook"
+
+	defaultSinks   = sinkComment + "," + sinkCheckRun
+	webhookTimeout = 30 * time.Second
+)
+
+// Report is a dependency-diff report handed to the sinks.
+type Report struct {
+	// Base and Head are the references the dependencies are compared between.
+	Base string
+	Head string
+	// Dependencies are the dependency-diffs left after applying the dependency policy.
+	Dependencies []pkg.DependencyCheckResult
+	// Markdown is the rendered report.
+	Markdown string
+
+	decisions  policyDecisions
+	violations []gateViolation
+	gate       *scoreGate
+
+	// markdownErr kept the report from being rendered, which only fails the sinks writing the markdown.
+	markdownErr error
+}
+
+// markdown returns the rendered report, or the error which kept it from being rendered.
+func (r *Report) markdown() (string, error) {
+	if r.markdownErr != nil {
+		return "", fmt.Errorf("error formatting results as markdown: %w", r.markdownErr)
+	}
+	return r.Markdown, nil
+}
+
+// Sink writes a dependency-diff report somewhere, e.g. to the pull request.
+type Sink interface {
+	// Name identifies the sink in errors.
+	Name() string
+	// Write writes the report.
+	Write(ctx context.Context, r *Report) error
+}
+
+// sinkConfig holds what the sinks need besides the report.
+type sinkConfig struct {
+	ghClient        *github.Client
+	owner           string
+	repo            string
+	prNumber        int
+	headSHA         string
+	commentMode     string
+	stepSummaryFile string
+	reportFile      string
+	webhookURL      string
+}
+
+// newSinks creates the sinks named in the comma-separated names. By default, the report is written to the
+// pull request and, when running in GitHub Actions, to the job step summary.
+func newSinks(names string, cfg *sinkConfig) ([]Sink, error) {
+	if strings.TrimSpace(names) == "" {
+		names = defaultSinks
+		if cfg.stepSummaryFile != "" {
+			names += "," + sinkStepSummary
+		}
+	}
+	sinks := []Sink{}
+	for _, name := range strings.Split(names, ",") {
+		switch name = strings.TrimSpace(name); name {
+		case sinkComment:
+			sinks = append(sinks, &commentSink{
+				ghClient: cfg.ghClient, owner: cfg.owner, repo: cfg.repo, prNumber: cfg.prNumber, mode: cfg.commentMode,
+			})
+		case sinkCheckRun:
+			sinks = append(sinks, &checkRunSink{
+				ghClient: cfg.ghClient, owner: cfg.owner, repo: cfg.repo, headSHA: cfg.headSHA,
+			})
+		case sinkStepSummary:
+			if cfg.stepSummaryFile == "" {
+				return nil, fmt.Errorf("%w: step summary file", errEmpty)
+			}
+			sinks = append(sinks, &stepSummarySink{path: cfg.stepSummaryFile})
+		case sinkFile:
+			if cfg.reportFile == "" {
+				return nil, fmt.Errorf("%w: report file", errEmpty)
+			}
+			sinks = append(sinks, &fileSink{name: sinkFile, path: cfg.reportFile})
+		case sinkWebhook:
+			if cfg.webhookURL == "" {
+				return nil, fmt.Errorf("%w: webhook url", errEmpty)
+			}
+			sinks = append(sinks, &webhookSink{url: cfg.webhookURL, client: &http.Client{Timeout: webhookTimeout}})
+		default:
+			return nil, fmt.Errorf("%w: sink %q", errInvalid, name)
+		}
+	}
+	return sinks, nil
+}
+
+// writeToSinks writes the report to every sink, even if some of them fail, and returns the failures together.
+func writeToSinks(ctx context.Context, sinks []Sink, r *Report) error {
+	var sinkErrs sinkErrors
+	for _, s := range sinks {
+		if err := s.Write(ctx, r); err != nil {
+			sinkErrs = append(sinkErrs, fmt.Errorf("%s: %w", s.Name(), err))
+		}
+	}
+	if len(sinkErrs) > 0 {
+		return sinkErrs
+	}
+	return nil
+}
+
+// sinkErrors are the failures of the sinks, each wrapped with the name of its sink.
+// It matches errSinks and every wrapped error with errors.Is.
+type sinkErrors []error
+
+func (e sinkErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return fmt.Sprintf("%v: %s", errSinks, strings.Join(msgs, "; "))
+}
+
+func (e sinkErrors) Is(target error) bool {
+	if target == errSinks {
+		return true
+	}
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// commentSink writes the report to a pull request comment.
+type commentSink struct {
+	ghClient *github.Client
+	owner    string
+	repo     string
+	prNumber int
+	mode     string
+}
+
+func (s *commentSink) Name() string { return sinkComment }
+
+func (s *commentSink) Write(ctx context.Context, r *Report) error {
+	markdown, err := r.markdown()
+	if err != nil {
+		return err
+	}
+	return writeToComment(ctx, s.ghClient, s.owner, s.repo, s.prNumber, &markdown, s.mode)
+}
+
+// checkRunSink visualizes the dependency-diffs as check run annotations.
+type checkRunSink struct {
+	ghClient *github.Client
+	owner    string
+	repo     string
+	headSHA  string
+}
+
+func (s *checkRunSink) Name() string { return sinkCheckRun }
+
+func (s *checkRunSink) Write(ctx context.Context, r *Report) error {
+	return visualizeToCheckRun(ctx, s.ghClient, s.owner, s.repo, s.headSHA, r.Dependencies, r.decisions, r.violations)
+}
+
+// stepSummarySink appends the report and the score gate violations to the job step summary.
+type stepSummarySink struct {
+	path string
+}
+
+func (s *stepSummarySink) Name() string { return sinkStepSummary }
+
+func (s *stepSummarySink) Write(ctx context.Context, r *Report) error {
+	if _, err := r.markdown(); err != nil {
+		return err
+	}
+	summary := *r
+	if len(r.violations) > 0 {
+		summary.Markdown += "\n\n" + violationsAsMarkdown(r.violations)
+	}
+	f := &fileSink{name: sinkStepSummary, path: s.path, appendTo: true}
+	return f.Write(ctx, &summary)
+}
+
+// fileSink writes the report to a file, as markdown by default.
+type fileSink struct {
+	name string
+	path string
+	// format is the format of the file, one of formatMarkdown, formatJSON or formatSARIF.
+	format string
+	// workspace is used to locate the manifests in the SARIF log.
+	workspace string
+	// appendTo appends the report to the file instead of overwriting it, e.g. for the job step summary.
+	appendTo bool
+}
+
+func (s *fileSink) Name() string { return s.name }
+
+func (s *fileSink) Write(ctx context.Context, r *Report) error {
+	markdown, markdownErr := r.markdown()
+	if markdownErr != nil && s.format != formatJSON && s.format != formatSARIF {
+		return markdownErr
+	}
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if s.appendTo {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+	f, err := os.OpenFile(s.path, flags, 0o644)
+	if err != nil {
+		return fmt.Errorf("error opening file (%s): %w", s.path, err)
+	}
+	defer f.Close()
+	switch s.format {
+	case formatJSON:
+		return dependencydiffResultsAsJSON(r.Dependencies, r.Base, r.Head, f)
+	case formatSARIF:
+		return dependencydiffResultsAsSARIF(r.Dependencies, r.gate, r.Head, s.workspace, f)
+	default:
+		if _, err := io.WriteString(f, markdown+"\n"); err != nil {
+			return fmt.Errorf("error writing file (%s): %w", s.path, err)
+		}
+		return nil
+	}
+}
+
+// webhookSink posts the JSON results to a URL.
+type webhookSink struct {
+	url    string
+	client *http.Client
+}
+
+func (s *webhookSink) Name() string { return sinkWebhook }
+
+func (s *webhookSink) Write(ctx context.Context, r *Report) error {
+	body := &bytes.Buffer{}
+	if err := dependencydiffResultsAsJSON(r.Dependencies, r.Base, r.Head, body); err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, body)
+	if err != nil {
+		return fmt.Errorf("error creating the webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error posting to the webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("%w: webhook status %d", errInvalid, resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,231 @@
+// Copyright 2022 Security Scorecard Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//nolint
+package dependencydiff
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ossf/scorecard/v4/pkg"
+)
+
+// recordingSink records the reports written to it and fails with err, if set.
+type recordingSink struct {
+	name    string
+	err     error
+	written int
+}
+
+func (s *recordingSink) Name() string { return s.name }
+
+func (s *recordingSink) Write(ctx context.Context, r *Report) error {
+	s.written++
+	return s.err
+}
+
+func Test_newSinks(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name      string
+		names     string
+		cfg       sinkConfig
+		wantNames []string
+		wantErr   error
+	}{
+		{name: "Default", names: "", wantNames: []string{sinkComment, sinkCheckRun}},
+		{
+			name:      "DefaultInGitHubActions",
+			names:     "",
+			cfg:       sinkConfig{stepSummaryFile: "summary.md"},
+			wantNames: []string{sinkComment, sinkCheckRun, sinkStepSummary},
+		},
+		{
+			name:      "All",
+			names:     "comment, check-run,step-summary,file,webhook",
+			cfg:       sinkConfig{stepSummaryFile: "summary.md", reportFile: "report.md", webhookURL: "http://localhost"},
+			wantNames: []string{sinkComment, sinkCheckRun, sinkStepSummary, sinkFile, sinkWebhook},
+		},
+		{name: "UnknownSink", names: "email", wantErr: errInvalid},
+		{name: "FileWithoutPath", names: "file", wantErr: errEmpty},
+		{name: "WebhookWithoutURL", names: "webhook", wantErr: errEmpty},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			sinks, err := newSinks(tt.names, &tt.cfg)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("newSinks() error = %v, want %v", err, tt.wantErr)
+			}
+			names := []string{}
+			for _, s := range sinks {
+				names = append(names, s.Name())
+			}
+			if tt.wantErr == nil && !cmp.Equal(tt.wantNames, names) {
+				t.Errorf("-want, +got:\n%s", cmp.Diff(tt.wantNames, names))
+			}
+		})
+	}
+}
+
+func Test_writeToSinks(t *testing.T) {
+	t.Parallel()
+	failing := &recordingSink{name: "failing", err: errInvalid}
+	working := &recordingSink{name: "working"}
+	err := writeToSinks(context.Background(), []Sink{failing, working}, &Report{})
+	if !errors.Is(err, errSinks) {
+		t.Errorf("writeToSinks() error = %v, want %v", err, errSinks)
+	}
+	if !errors.Is(err, errInvalid) {
+		t.Errorf("writeToSinks() error = %v, want it to wrap %v", err, errInvalid)
+	}
+	if !strings.Contains(err.Error(), "failing: ") {
+		t.Errorf("writeToSinks() error = %v, want the name of the failing sink", err)
+	}
+	if failing.written != 1 || working.written != 1 {
+		t.Errorf("written = %d, %d, want every sink to be written once", failing.written, working.written)
+	}
+	if err := writeToSinks(context.Background(), []Sink{working}, &Report{}); err != nil {
+		t.Errorf("writeToSinks() error = %v, want nil", err)
+	}
+}
+
+func Test_writeToSinks_markdownError(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	report := &Report{
+		Dependencies: []pkg.DependencyCheckResult{testDependency("lib", pkg.Added, map[string]int{"Maintained": 5})},
+		markdownErr:  errInvalid,
+	}
+	results := filepath.Join(dir, "results.json")
+	sinks := []Sink{
+		&stepSummarySink{path: filepath.Join(dir, "summary.md")},
+		&fileSink{name: sinkFile, path: filepath.Join(dir, "report.md")},
+		&fileSink{name: "results-file", path: results, format: formatJSON},
+	}
+	err := writeToSinks(context.Background(), sinks, report)
+	if !errors.Is(err, errSinks) {
+		t.Fatalf("writeToSinks() error = %v, want %v", err, errSinks)
+	}
+	for _, name := range []string{sinkStepSummary, sinkFile} {
+		if !strings.Contains(err.Error(), name+": error formatting results as markdown") {
+			t.Errorf("writeToSinks() error = %v, want the markdown error of %s", err, name)
+		}
+	}
+	if strings.Contains(err.Error(), "results-file") {
+		t.Errorf("writeToSinks() error = %v, want the JSON results written", err)
+	}
+	if _, err := os.Stat(results); err != nil {
+		t.Errorf("results file not written: %v", err)
+	}
+}
+
+func Test_fileSink(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	report := &Report{
+		Base:         "main",
+		Head:         "feature",
+		Dependencies: []pkg.DependencyCheckResult{testDependency("lib", pkg.Added, map[string]int{"Maintained": 5})},
+		Markdown:     "report",
+	}
+
+	summary := filepath.Join(dir, "summary.md")
+	if err := os.WriteFile(summary, []byte("previous step\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	s := &fileSink{name: sinkStepSummary, path: summary, appendTo: true}
+	if err := s.Write(context.Background(), report); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	content, err := os.ReadFile(summary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(content), "previous step\nreport\n"; got != want {
+		t.Errorf("step summary = %q, want %q", got, want)
+	}
+
+	report.violations = []gateViolation{{dependency: "lib", version: "v1.0.0", check: "Maintained", score: 5, threshold: 7}}
+	if err := (&stepSummarySink{path: summary}).Write(context.Background(), report); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	content, err = os.ReadFile(summary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(content), "previous step\nreport\nreport") ||
+		!strings.Contains(string(content), "| lib | v1.0.0 | Maintained |") {
+		t.Errorf("step summary = %q, want the report with the violations appended", content)
+	}
+
+	results := filepath.Join(dir, "results.json")
+	s = &fileSink{name: "results-file", path: results, format: formatJSON}
+	if err := s.Write(context.Background(), report); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	content, err = os.ReadFile(results)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := jsonDependencyDiffResults{}
+	if err := json.Unmarshal(content, &got); err != nil {
+		t.Fatalf("json.Unmarshal: %v", err)
+	}
+	if len(got.Dependencies) != 1 || got.Head != "feature" {
+		t.Errorf("results = %+v, want the report dependencies", got)
+	}
+}
+
+func Test_webhookSink(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		status  int
+		wantErr bool
+	}{
+		{name: "Accepted", status: http.StatusAccepted},
+		{name: "Rejected", status: http.StatusForbidden, wantErr: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var received jsonDependencyDiffResults
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				json.NewDecoder(r.Body).Decode(&received)
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+			s := &webhookSink{url: server.URL, client: server.Client()}
+			err := s.Write(context.Background(), &Report{Base: "main", Head: "feature"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Write() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if received.Base != "main" {
+				t.Errorf("webhook received %+v, want the JSON results", received)
+			}
+		})
+	}
+}
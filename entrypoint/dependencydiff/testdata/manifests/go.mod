@@ -0,0 +1,10 @@
+module example.com/app
+
+go 1.18
+
+require github.com/spf13/cobra v1.5.0
+
+require (
+	github.com/google/go-cmp v0.5.8
+	github.com/google/go-github/v45 v45.2.0
+)
@@ -0,0 +1,198 @@
+// Copyright 2022 Security Scorecard Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dependencydiff
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ossf/scorecard/v4/checker"
+	docs "github.com/ossf/scorecard/v4/docs/checks"
+	"github.com/ossf/scorecard/v4/pkg"
+)
+
+// checkDelta is the score change of a check between the old and the new version of a dependency.
+type checkDelta struct {
+	name     string
+	oldScore int
+	newScore int
+}
+
+// dependencyUpdate pairs the old and the new version of an updated dependency.
+type dependencyUpdate struct {
+	oldResult    *pkg.DependencyCheckResult
+	newResult    pkg.DependencyCheckResult
+	deltas       []checkDelta
+	oldAggregate float64
+	newAggregate float64
+}
+
+// findUpdates finds the updated dependencies. The data source reports an update as a removed and an added
+// dependency sharing the same name, so we pair them here. Dependencies reported as updated by the data source
+// itself are kept, even without an old version to compare with.
+func findUpdates(added, removed map[string]pkg.DependencyCheckResult) (map[string]dependencyUpdate, error) {
+	doc, err := docs.Read()
+	if err != nil {
+		return nil, fmt.Errorf("error reading docs: %w", err)
+	}
+	updates := map[string]dependencyUpdate{}
+	for name := range added {
+		newResult := added[name]
+		oldResult, paired := removed[name]
+		if !paired && (newResult.ChangeType == nil || *newResult.ChangeType != pkg.Updated) {
+			continue
+		}
+		if paired && !sameEcosystem(&oldResult, &newResult) {
+			continue
+		}
+		u := dependencyUpdate{
+			newResult:    newResult,
+			oldAggregate: checker.InconclusiveResultScore,
+		}
+		u.newAggregate, err = aggregateScore(&newResult, doc)
+		if err != nil {
+			return nil, err
+		}
+		if paired {
+			u.oldResult = &oldResult
+			u.oldAggregate, err = aggregateScore(&oldResult, doc)
+			if err != nil {
+				return nil, err
+			}
+			u.deltas = checkDeltas(&oldResult, &newResult)
+		}
+		updates[name] = u
+	}
+	return updates, nil
+}
+
+// lowersScore tells whether the update lowers the aggregate score, or any check score when the aggregate
+// scores aren't available.
+func (u *dependencyUpdate) lowersScore() bool {
+	if u.oldAggregate != checker.InconclusiveResultScore && u.newAggregate != checker.InconclusiveResultScore {
+		return u.newAggregate < u.oldAggregate
+	}
+	for _, d := range u.deltas {
+		if d.newScore < d.oldScore {
+			return true
+		}
+	}
+	return false
+}
+
+// sourceChanged tells whether the update moves the dependency to a different source repository, which might
+// indicate a takeover.
+func (u *dependencyUpdate) sourceChanged() bool {
+	if u.oldResult == nil || u.oldResult.SourceRepository == nil || u.newResult.SourceRepository == nil {
+		return false
+	}
+	return normalizeRepoURL(*u.oldResult.SourceRepository) != normalizeRepoURL(*u.newResult.SourceRepository)
+}
+
+// warnings returns the warnings about the update, if any.
+func (u *dependencyUpdate) warnings() []string {
+	warnings := []string{}
+	if u.lowersScore() {
+		warnings = append(warnings, "This update lowers the Scorecard score of the dependency.")
+	}
+	if u.sourceChanged() {
+		warnings = append(warnings, fmt.Sprintf(
+			"The source repository changed from %s to %s, please make sure this isn't a takeover.",
+			*u.oldResult.SourceRepository, *u.newResult.SourceRepository,
+		))
+	}
+	return warnings
+}
+
+func aggregateScore(d *pkg.DependencyCheckResult, doc docs.Doc) (float64, error) {
+	scResult := d.ScorecardResultWithError.ScorecardResult
+	if scResult == nil {
+		return checker.InconclusiveResultScore, nil
+	}
+	score, err := scResult.GetAggregateScore(doc)
+	if err != nil {
+		return checker.InconclusiveResultScore, fmt.Errorf("error getting the aggregate score: %w", err)
+	}
+	return score, nil
+}
+
+// checkDeltas returns the checks present in both results, sorted by name.
+func checkDeltas(oldResult, newResult *pkg.DependencyCheckResult) []checkDelta {
+	oldSc, newSc := oldResult.ScorecardResultWithError.ScorecardResult, newResult.ScorecardResultWithError.ScorecardResult
+	if oldSc == nil || newSc == nil {
+		return nil
+	}
+	oldScores := map[string]int{}
+	for _, c := range oldSc.Checks {
+		oldScores[c.Name] = c.Score
+	}
+	deltas := []checkDelta{}
+	for _, c := range newSc.Checks {
+		oldScore, ok := oldScores[c.Name]
+		if !ok || oldScore == checker.InconclusiveResultScore || c.Score == checker.InconclusiveResultScore {
+			continue
+		}
+		deltas = append(deltas, checkDelta{name: c.Name, oldScore: oldScore, newScore: c.Score})
+	}
+	sort.Slice(deltas, func(i, j int) bool { return deltas[i].name < deltas[j].name })
+	return deltas
+}
+
+func sameEcosystem(a, b *pkg.DependencyCheckResult) bool {
+	if a.Ecosystem == nil || b.Ecosystem == nil {
+		return true
+	}
+	return strings.EqualFold(*a.Ecosystem, *b.Ecosystem)
+}
+
+func normalizeRepoURL(u string) string {
+	u = strings.ToLower(strings.TrimSpace(u))
+	for _, prefix := range []string{"https://", "http://", "git+", "git://", "www."} {
+		u = strings.TrimPrefix(u, prefix)
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(u, "/"), ".git")
+}
+
+// updateAsMarkdown renders the version and score changes of an updated dependency.
+func updateAsMarkdown(u *dependencyUpdate, depsDevTag string) string {
+	result := updatedTag() + depsDevTag
+	if u.oldAggregate != checker.InconclusiveResultScore && u.newAggregate != checker.InconclusiveResultScore {
+		result += fmt.Sprintf("`Score: %.1f → %.1f` ", u.oldAggregate, u.newAggregate)
+	} else if u.newAggregate != checker.InconclusiveResultScore {
+		result += scoreTag(u.newAggregate)
+	}
+	name := u.newResult.Name
+	if u.newResult.SourceRepository != nil {
+		name = "[" + name + "]" + "(" + *u.newResult.SourceRepository + ")"
+	}
+	result += " " + name
+	switch {
+	case u.oldResult != nil && u.oldResult.Version != nil && u.newResult.Version != nil:
+		result += fmt.Sprintf(" @ %s → %s", *u.oldResult.Version, *u.newResult.Version)
+	case u.newResult.Version != nil:
+		result += fmt.Sprintf(" @ %s", *u.newResult.Version)
+	}
+	for _, d := range u.deltas {
+		if d.oldScore == d.newScore {
+			continue
+		}
+		result += fmt.Sprintf("\n  - %s: %d → %d", d.name, d.oldScore, d.newScore)
+	}
+	for _, w := range u.warnings() {
+		result += fmt.Sprintf("\n  - :warning: %s", w)
+	}
+	return result
+}
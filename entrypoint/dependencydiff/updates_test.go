@@ -0,0 +1,87 @@
+// Copyright 2022 Security Scorecard Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//nolint
+package dependencydiff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ossf/scorecard/v4/pkg"
+)
+
+func Test_findUpdates(t *testing.T) {
+	t.Parallel()
+	withVersionAndSource := func(d pkg.DependencyCheckResult, version, source string) pkg.DependencyCheckResult {
+		d.Version, d.SourceRepository = &version, &source
+		return d
+	}
+	deps := []pkg.DependencyCheckResult{
+		withVersionAndSource(
+			testDependency("lib", pkg.Removed, map[string]int{"Maintained": 8, "Code-Review": 10}),
+			"v1.0.0", "https://github.com/owner/lib",
+		),
+		withVersionAndSource(
+			testDependency("lib", pkg.Added, map[string]int{"Maintained": 3, "Code-Review": 10}),
+			"v2.0.0", "https://github.com/owner/lib.git",
+		),
+		withVersionAndSource(
+			testDependency("hijacked", pkg.Removed, map[string]int{"Maintained": 5}),
+			"v1.0.0", "https://github.com/owner/hijacked",
+		),
+		withVersionAndSource(
+			testDependency("hijacked", pkg.Added, map[string]int{"Maintained": 5}),
+			"v1.0.1", "https://github.com/attacker/hijacked",
+		),
+		testDependency("new", pkg.Added, map[string]int{"Maintained": 10}),
+		testDependency("gone", pkg.Removed, nil),
+	}
+	added, removed := dependencySliceToMaps(deps)
+	updates, err := findUpdates(added, removed)
+	if err != nil {
+		t.Fatalf("findUpdates: %v", err)
+	}
+	if len(updates) != 2 {
+		t.Fatalf("got %d updates, want 2", len(updates))
+	}
+
+	lib := updates["lib"]
+	wantDeltas := []checkDelta{
+		{name: "Code-Review", oldScore: 10, newScore: 10},
+		{name: "Maintained", oldScore: 8, newScore: 3},
+	}
+	if !cmp.Equal(wantDeltas, lib.deltas, cmp.AllowUnexported(checkDelta{})) {
+		t.Errorf("deltas: -want, +got:\n%s", cmp.Diff(wantDeltas, lib.deltas, cmp.AllowUnexported(checkDelta{})))
+	}
+	if !lib.lowersScore() || lib.sourceChanged() {
+		t.Errorf("lib: lowersScore = %v, sourceChanged = %v, want true, false", lib.lowersScore(), lib.sourceChanged())
+	}
+	markdown := updateAsMarkdown(&lib, "")
+	for _, want := range []string{"`Score: 9.0 → 6.5`", "@ v1.0.0 → v2.0.0", "Maintained: 8 → 3", ":warning:"} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("updateAsMarkdown() = %q, want it to contain %q", markdown, want)
+		}
+	}
+	if strings.Contains(markdown, "Code-Review") {
+		t.Errorf("updateAsMarkdown() = %q, unchanged checks should be left out", markdown)
+	}
+
+	hijacked := updates["hijacked"]
+	if hijacked.lowersScore() || !hijacked.sourceChanged() {
+		t.Errorf("hijacked: lowersScore = %v, sourceChanged = %v, want false, true",
+			hijacked.lowersScore(), hijacked.sourceChanged())
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2022 Security Scorecard Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package entrypoint
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/ossf/scorecard-action/policies"
+)
+
+var errPolicyViolations = errors.New("checks violate the enforced Scorecard policy")
+
+// printViolations prints the policy violations as a table, with a warning for the logging checks.
+func printViolations(w io.Writer, violations []policies.Violation) {
+	if len(violations) == 0 {
+		fmt.Fprintln(w, "No Scorecard policy violations.")
+		return
+	}
+	fmt.Fprintln(w, "Scorecard policy violations:")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CHECK\tSCORE\tPOLICY\tMODE")
+	for _, v := range violations {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%s\n", v.Check, v.Score, v.Threshold, v.Mode)
+	}
+	tw.Flush()
+	for _, v := range violations {
+		if v.Mode == policies.ModeLogging {
+			fmt.Fprintf(w, "warning: %s scores %d, below its policy of %d\n", v.Check, v.Score, v.Threshold)
+		}
+	}
+}
+
+// violationsAsMarkdown renders the policy violations for the job step summary.
+func violationsAsMarkdown(violations []policies.Violation) string {
+	out := "### Policy violations\n\n"
+	if len(violations) == 0 {
+		return out + "No check violates the policy.\n\n"
+	}
+	out += "| Check | Score | Policy | Mode |\n| --- | --- | --- | --- |\n"
+	for _, v := range violations {
+		mode := ":warning: " + v.Mode
+		if v.Mode == policies.ModeEnforced {
+			mode = ":x: " + v.Mode
+		}
+		out += fmt.Sprintf("| %s | %d | %d | %s |\n", v.Check, v.Score, v.Threshold, mode)
+	}
+	return out + "\n"
+}
+
+// failingViolations returns the enforced violations which fail the run. When failing on regressions
+// only, the violations already in the baseline are let through.
+func failingViolations(violations []policies.Violation, deltas []checkDelta, regressionsOnly bool,
+) []policies.Violation {
+	enforced := policies.Enforced(violations)
+	if !regressionsOnly {
+		return enforced
+	}
+	newFailures := map[string]bool{}
+	for _, d := range deltas {
+		if d.state == stateNewFailure {
+			newFailures[d.name] = true
+		}
+	}
+	failing := []policies.Violation{}
+	for _, v := range enforced {
+		if newFailures[v.Check] {
+			failing = append(failing, v)
+		}
+	}
+	return failing
+}
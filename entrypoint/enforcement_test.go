@@ -0,0 +1,88 @@
+// Copyright 2022 Security Scorecard Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package entrypoint
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/ossf/scorecard-action/policies"
+)
+
+func TestPolicyEnforcement(t *testing.T) {
+	t.Parallel()
+	violations := []policies.Violation{
+		{Check: "Branch-Protection", Score: 3, Threshold: 8, Mode: policies.ModeEnforced},
+		{Check: "Code-Review", Score: 2, Threshold: 8, Mode: policies.ModeLogging},
+		{Check: "Maintained", Score: 5, Threshold: 8, Mode: policies.ModeEnforced},
+	}
+	deltas := []checkDelta{
+		{name: "Branch-Protection", state: stateNewFailure},
+		{name: "Code-Review", state: stateNewFailure},
+		{name: "Maintained", state: stateUnchanged},
+	}
+	tests := []struct {
+		name            string
+		regressionsOnly bool
+		want            []string
+	}{
+		{
+			name: "AllEnforced",
+			want: []string{"Branch-Protection", "Maintained"},
+		},
+		{
+			name:            "RegressionsOnly",
+			regressionsOnly: true,
+			want:            []string{"Branch-Protection"},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := []string{}
+			for _, v := range failingViolations(violations, deltas, tt.regressionsOnly) {
+				got = append(got, v.Check)
+			}
+			if !cmp.Equal(tt.want, got) {
+				t.Errorf("failingViolations(): -want, +got:\n%s", cmp.Diff(tt.want, got))
+			}
+		})
+	}
+
+	out := &bytes.Buffer{}
+	printViolations(out, violations)
+	for _, want := range []string{
+		"CHECK              SCORE  POLICY  MODE",
+		"Branch-Protection  3      8       enforced",
+		"warning: Code-Review scores 2, below its policy of 8",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("printViolations() = %q, want it to contain %q", out, want)
+		}
+	}
+	markdown := violationsAsMarkdown(violations)
+	for _, want := range []string{
+		"| Branch-Protection | 3 | 8 | :x: enforced |",
+		"| Code-Review | 2 | 8 | :warning: logging |",
+	} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("violationsAsMarkdown() = %q, want it to contain %q", markdown, want)
+		}
+	}
+}
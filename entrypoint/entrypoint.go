@@ -15,15 +15,21 @@
 package entrypoint
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"strconv"
 
 	"github.com/spf13/cobra"
 
+	"github.com/ossf/scorecard-action/github"
 	"github.com/ossf/scorecard-action/options"
+	"github.com/ossf/scorecard-action/policies"
 	sccmd "github.com/ossf/scorecard/v4/cmd"
+	docs "github.com/ossf/scorecard/v4/docs/checks"
 	scopts "github.com/ossf/scorecard/v4/options"
 )
 
@@ -34,6 +40,12 @@ func New() (*cobra.Command, error) {
 	if err != nil {
 		return nil, fmt.Errorf("creating new options: %w", err)
 	}
+	return NewWithOptions(opts)
+}
+
+// NewWithOptions creates a new scorecard command from options which were already read,
+// e.g. to share the GitHub event with the dependency-diff.
+func NewWithOptions(opts *options.Options) (*cobra.Command, error) {
 	if err := opts.Validate(); err != nil {
 		return nil, fmt.Errorf("validating options: %w", err)
 	}
@@ -60,7 +72,8 @@ func New() (*cobra.Command, error) {
 	var out, stdout *os.File
 	actionCmd.PreRunE = func(cmd *cobra.Command, args []string) error {
 		// TODO: the results file should be completed and validated by the time we get it.
-		if scOpts.ResultsFile != "" {
+		// In batch mode, the results of each repository go to their own file.
+		if scOpts.ResultsFile != "" && !opts.IsBatch() {
 			var err error
 			resultsFilePath := fmt.Sprintf("%v/%v", opts.GithubWorkspace, scOpts.ResultsFile)
 			out, err = os.Create(resultsFilePath)
@@ -78,15 +91,98 @@ func New() (*cobra.Command, error) {
 		return nil
 	}
 
-	actionCmd.PersistentPostRun = func(cmd *cobra.Command, args []string) {
-		if out != nil {
-			if _, err = out.Seek(0, io.SeekStart); err == nil {
-				// nolint:errcheck
-				_, _ = io.Copy(stdout, out)
+	// Run Scorecard in-process to keep the results, so that they can be rendered in several formats
+	// and summarized without running Scorecard again.
+	actionCmd.Run = nil
+	// The errors fail the run, the usage would only end up in the results file.
+	actionCmd.SilenceUsage = true
+	actionCmd.RunE = func(cmd *cobra.Command, args []string) error {
+		// Cobra skips the post-run hooks after an error, so the results file is echoed and closed here.
+		defer func() {
+			if out != nil {
+				if _, err := out.Seek(0, io.SeekStart); err == nil {
+					// nolint:errcheck
+					_, _ = io.Copy(stdout, out)
+				}
+				_ = out.Close()
+			}
+			if stdout != nil {
+				os.Stdout = stdout
+			}
+		}()
+		checkDocs, err := docs.Read()
+		if err != nil {
+			return fmt.Errorf("reading check docs: %w", err)
+		}
+		if opts.IsBatch() {
+			return runBatch(context.Background(), opts, checkDocs)
+		}
+		result, pol, err := runScorecard(context.Background(), scOpts)
+		if err != nil {
+			return err
+		}
+		// The policy of the action, with its logging mode, which Scorecard's policy lacks.
+		p, err := policies.ReadFile(scOpts.PolicyFile)
+		if err != nil {
+			return fmt.Errorf("reading policy: %w", err)
+		}
+		r := &resultsRenderer{result: result, checkDocs: checkDocs, pol: pol, scOpts: scOpts}
+		if opts.BaselineFile != "" {
+			r.baseline, err = readBaseline(workspacePath(opts.GithubWorkspace, opts.BaselineFile))
+			if err != nil {
+				return err
 			}
-			_ = out.Close()
+			r.deltas = compareWithBaseline(r.baseline, result, p)
 		}
-		os.Stdout = stdout
+		var violations []policies.Violation
+		if opts.EnforcePolicy {
+			violations = p.Evaluate(result)
+			// Printed to stderr, since stdout goes to the results file.
+			printViolations(os.Stderr, violations)
+		}
+		// Stdout is redirected to the results file by PreRunE.
+		if err := r.render(os.Stdout, scOpts.Format); err != nil {
+			return err
+		}
+		if err := r.writeAdditionalResults(opts.GithubWorkspace, opts.AdditionalResults); err != nil {
+			return err
+		}
+		if opts.GithubStepSummary != "" {
+			summary, err := r.markdown()
+			if err != nil {
+				return err
+			}
+			if opts.EnforcePolicy {
+				summary += violationsAsMarkdown(violations)
+			}
+			if err := appendToStepSummary(opts.GithubStepSummary, summary); err != nil {
+				return err
+			}
+		}
+		if opts.GithubOutput != "" {
+			outputs, err := scorecardOutputs(
+				result, checkDocs, p, fmt.Sprintf("%v/%v", opts.GithubWorkspace, scOpts.ResultsFile),
+			)
+			if err != nil {
+				return err
+			}
+			outputs["ref"] = opts.ScoredRef()
+			if r.baseline != nil {
+				outputs["regressions"] = strconv.Itoa(regressions(r.deltas))
+			}
+			if err := github.WriteOutputs(opts.GithubOutput, outputs); err != nil {
+				return fmt.Errorf("writing outputs: %w", err)
+			}
+		}
+		// When failing on regressions, the policy only fails the run for the checks newly violating it.
+		regressionsOnly := r.baseline != nil && opts.FailOnRegression
+		if failing := failingViolations(violations, r.deltas, regressionsOnly); len(failing) > 0 {
+			return fmt.Errorf("%w: %d check(s)", errPolicyViolations, len(failing))
+		}
+		if n := regressions(r.deltas); opts.FailOnRegression && n > 0 {
+			return fmt.Errorf("%w: %d check(s)", errRegressions, n)
+		}
+		return nil
 	}
 
 	var hideErrs []error
@@ -113,6 +209,7 @@ func New() (*cobra.Command, error) {
 
 	// Add sub-commands.
 	actionCmd.AddCommand(printConfigCmd(opts))
+	actionCmd.AddCommand(NewPolicyCommand())
 
 	return actionCmd, nil
 }
@@ -129,3 +226,11 @@ func printConfigCmd(o *options.Options) *cobra.Command {
 }
 
 var errHideFlags = errors.New("errors occurred while trying to hide scorecard flags")
+
+// workspacePath returns the path of a file given relative to the workspace, or the file if its path is absolute.
+func workspacePath(workspace, file string) string {
+	if filepath.IsAbs(file) {
+		return file
+	}
+	return filepath.Join(workspace, file)
+}
@@ -0,0 +1,62 @@
+// Copyright OpenSSF Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package entrypoint
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ossf/scorecard-action/policies"
+	"github.com/ossf/scorecard/v4/checker"
+	docs "github.com/ossf/scorecard/v4/docs/checks"
+	"github.com/ossf/scorecard/v4/pkg"
+)
+
+// scorecardOutputs returns the action outputs for the Scorecard results.
+func scorecardOutputs(result *pkg.ScorecardResult, checkDocs docs.Doc, pol *policies.Policy,
+	resultsFile string,
+) (map[string]string, error) {
+	score, err := result.GetAggregateScore(checkDocs)
+	if err != nil {
+		return nil, fmt.Errorf("getting aggregate score: %w", err)
+	}
+	outputs := map[string]string{
+		"score":             aggregateOutputScore(score),
+		"results_file":      resultsFile,
+		"policy_violations": strconv.Itoa(len(pol.Evaluate(result))),
+	}
+	for _, c := range result.Checks {
+		outputs[checkScoreOutput(c.Name)] = ""
+		if c.Score != checker.InconclusiveResultScore {
+			outputs[checkScoreOutput(c.Name)] = strconv.Itoa(c.Score)
+		}
+	}
+	return outputs, nil
+}
+
+// checkScoreOutput returns the output name of a check score, e.g. check_code_review_score for Code-Review.
+func checkScoreOutput(check string) string {
+	return "check_" + strings.ReplaceAll(strings.ToLower(check), "-", "_") + "_score"
+}
+
+// aggregateOutputScore formats the aggregate score like Scorecard does, leaving it empty when inconclusive
+// so that it can't be mistaken for a low score. The check scores are left empty in the same way.
+func aggregateOutputScore(score float64) string {
+	if score == checker.InconclusiveResultScore {
+		return ""
+	}
+	return fmt.Sprintf("%.1f", score)
+}
@@ -0,0 +1,120 @@
+// Copyright OpenSSF Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package entrypoint
+
+import (
+	"os"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ossf/scorecard-action/policies"
+	"github.com/ossf/scorecard/v4/checker"
+	"github.com/ossf/scorecard/v4/checks"
+	docs "github.com/ossf/scorecard/v4/docs/checks"
+	"github.com/ossf/scorecard/v4/pkg"
+	"gopkg.in/yaml.v3"
+)
+
+func TestScorecardOutputs(t *testing.T) {
+	t.Parallel()
+	checkDocs, err := docs.Read()
+	if err != nil {
+		t.Fatalf("docs.Read: %v", err)
+	}
+	result := &pkg.ScorecardResult{
+		Checks: []checker.CheckResult{
+			{Name: "Code-Review", Score: 4},
+			{Name: "Fuzzing", Score: checker.InconclusiveResultScore},
+			{Name: "Maintained", Score: 10},
+			{Name: "SAST", Score: 0},
+		},
+	}
+	pol := &policies.Policy{
+		Policies: map[string]policies.CheckPolicy{
+			"Code-Review": {Mode: policies.ModeEnforced, Score: 5},
+			"Fuzzing":     {Mode: policies.ModeEnforced, Score: 5},
+			"Maintained":  {Mode: policies.ModeEnforced, Score: 5},
+			"SAST":        {Mode: policies.ModeDisabled, Score: 5},
+		},
+	}
+	got, err := scorecardOutputs(result, checkDocs, pol, "/github/workspace/results.sarif")
+	if err != nil {
+		t.Fatalf("scorecardOutputs: %v", err)
+	}
+	want := map[string]string{
+		"score":                   "5.2",
+		"results_file":            "/github/workspace/results.sarif",
+		"policy_violations":       "1",
+		"check_code_review_score": "4",
+		"check_fuzzing_score":     "",
+		"check_maintained_score":  "10",
+		"check_sast_score":        "0",
+	}
+	if !cmp.Equal(want, got) {
+		t.Errorf("-want, +got:\n%s", cmp.Diff(want, got))
+	}
+}
+
+// TestScorecardOutputsDeclared checks that action.yaml declares an output for each check of Scorecard,
+// as the outputs which aren't declared can't be read by the workflows.
+func TestScorecardOutputsDeclared(t *testing.T) {
+	t.Parallel()
+	checkDocs, err := docs.Read()
+	if err != nil {
+		t.Fatalf("docs.Read: %v", err)
+	}
+	content, err := os.ReadFile("../action.yaml")
+	if err != nil {
+		t.Fatalf("os.ReadFile: %v", err)
+	}
+	var action struct {
+		Outputs map[string]interface{} `yaml:"outputs"`
+	}
+	if err := yaml.Unmarshal(content, &action); err != nil {
+		t.Fatalf("yaml.Unmarshal: %v", err)
+	}
+
+	result := &pkg.ScorecardResult{}
+	for name := range checks.GetAll() {
+		result.Checks = append(result.Checks, checker.CheckResult{Name: name, Score: 10})
+	}
+	outputs, err := scorecardOutputs(result, checkDocs, &policies.Policy{}, "results.sarif")
+	if err != nil {
+		t.Fatalf("scorecardOutputs: %v", err)
+	}
+	var want, got []string
+	for name := range outputs {
+		if strings.HasPrefix(name, "check_") {
+			want = append(want, name)
+		}
+	}
+	for name := range action.Outputs {
+		if strings.HasPrefix(name, "check_") {
+			got = append(got, name)
+		}
+	}
+	sort.Strings(want)
+	sort.Strings(got)
+	if !cmp.Equal(want, got) {
+		t.Errorf("check outputs of action.yaml -want, +got:\n%s", cmp.Diff(want, got))
+	}
+	for name := range outputs {
+		if _, ok := action.Outputs[name]; !ok {
+			t.Errorf("output %s is not declared in action.yaml", name)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2022 Security Scorecard Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package entrypoint
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ossf/scorecard-action/policies"
+)
+
+// PolicyCommandName is the name of the policy command, which also runs outside GitHub Actions.
+const PolicyCommandName = "policy"
+
+// Formats of the policy diagnostics.
+const (
+	diagnosticsText   = "text"
+	diagnosticsJSON   = "json"
+	diagnosticsGitHub = "github"
+)
+
+var errInvalidDiagnosticsFormat = errors.New("invalid diagnostics format")
+
+// NewPolicyCommand creates the command to work with Scorecard policy files.
+func NewPolicyCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   PolicyCommandName,
+		Short: "Work with Scorecard policy files",
+	}
+	c.AddCommand(policyValidateCmd())
+	return c
+}
+
+func policyValidateCmd() *cobra.Command {
+	format := diagnosticsText
+	c := &cobra.Command{
+		Use:     "validate FILE...",
+		Short:   "Validate Scorecard policy files before they are used in a run",
+		Example: "  scorecard-action policy validate --format json .github/scorecard-policy.yml",
+		Args:    cobra.MinimumNArgs(1),
+		// The diagnostics tell what is wrong, the usage would only hide them.
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return validatePolicyFiles(cmd.OutOrStdout(), args, format)
+		},
+	}
+	c.Flags().StringVar(&format, "format", diagnosticsText, "diagnostics format [text, json, github]")
+	return c
+}
+
+// validatePolicyFiles writes the diagnostics of the policy files and fails if any of them is an error.
+func validatePolicyFiles(w io.Writer, files []string, format string) error {
+	switch format {
+	case diagnosticsText, diagnosticsJSON, diagnosticsGitHub:
+	default:
+		return fmt.Errorf("%w: %q", errInvalidDiagnosticsFormat, format)
+	}
+	diagnostics := []policies.Diagnostic{}
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("reading policy file: %w", err)
+		}
+		diagnostics = append(diagnostics, policies.Lint(file, content)...)
+	}
+	if err := writeDiagnostics(w, diagnostics, format); err != nil {
+		return err
+	}
+	if policies.HasErrors(diagnostics) {
+		return fmt.Errorf("%w: see the diagnostics", policies.ErrInvalidPolicy)
+	}
+	return nil
+}
+
+func writeDiagnostics(w io.Writer, diagnostics []policies.Diagnostic, format string) error {
+	if format == diagnosticsJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(diagnostics); err != nil {
+			return fmt.Errorf("encoding diagnostics: %w", err)
+		}
+		return nil
+	}
+	for _, d := range diagnostics {
+		line := d.String()
+		if format == diagnosticsGitHub {
+			// Workflow commands annotate the files of pull requests.
+			line = fmt.Sprintf("::%s file=%s,line=%d,col=%d::%s", d.Severity, d.File, d.Line, d.Column, d.Message)
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return fmt.Errorf("writing diagnostics: %w", err)
+		}
+	}
+	return nil
+}
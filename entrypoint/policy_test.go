@@ -0,0 +1,127 @@
+// Copyright 2022 Security Scorecard Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package entrypoint
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/ossf/scorecard-action/policies"
+)
+
+func TestValidatePolicyFiles(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "valid.yml")
+	invalid := filepath.Join(dir, "invalid.yml")
+	warning := filepath.Join(dir, "warning.yml")
+	for file, content := range map[string]string{
+		valid:   "version: 1\npolicies:\n  Fuzzing:\n    mode: enforced\n    score: 5\n",
+		invalid: "version: 1\npolicies:\n  Fuzzing:\n    mode: enforced\n    score: 11\n",
+		warning: "version: 1\npolicies:\n  Fuzzing:\n    mode: enforced\n",
+	} {
+		if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		name    string
+		files   []string
+		format  string
+		want    string
+		wantErr error
+	}{
+		{
+			name:   "Valid",
+			files:  []string{valid},
+			format: diagnosticsText,
+			want:   "",
+		},
+		{
+			name:   "Warning",
+			files:  []string{valid, warning},
+			format: diagnosticsText,
+			want:   warning + ":4:5: warning: missing score of Fuzzing, which never fails\n",
+		},
+		{
+			name:    "Text",
+			files:   []string{invalid},
+			format:  diagnosticsText,
+			want:    invalid + ":5:12: error: impossible score 11 of Fuzzing, scores are from 0 to 10\n",
+			wantErr: policies.ErrInvalidPolicy,
+		},
+		{
+			name:    "GitHub",
+			files:   []string{invalid},
+			format:  diagnosticsGitHub,
+			want:    "::error file=" + invalid + ",line=5,col=12::impossible score 11 of Fuzzing, scores are from 0 to 10\n",
+			wantErr: policies.ErrInvalidPolicy,
+		},
+		{
+			name:    "UnknownFormat",
+			files:   []string{valid},
+			format:  "xml",
+			wantErr: errInvalidDiagnosticsFormat,
+		},
+		{
+			name:    "MissingFile",
+			files:   []string{filepath.Join(dir, "missing.yml")},
+			format:  diagnosticsText,
+			wantErr: os.ErrNotExist,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			out := &bytes.Buffer{}
+			err := validatePolicyFiles(out, tt.files, tt.format)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("validatePolicyFiles() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, out.String()); diff != "" {
+				t.Errorf("diagnostics (-want +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestValidatePolicyFilesJSON(t *testing.T) {
+	t.Parallel()
+	file := filepath.Join(t.TempDir(), "policy.yml")
+	if err := os.WriteFile(file, []byte("version: 2\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	out := &bytes.Buffer{}
+	if err := validatePolicyFiles(out, []string{file}, diagnosticsJSON); !errors.Is(err, policies.ErrInvalidPolicy) {
+		t.Fatalf("validatePolicyFiles() error = %v, want %v", err, policies.ErrInvalidPolicy)
+	}
+	got := []policies.Diagnostic{}
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("decoding diagnostics: %v", err)
+	}
+	want := []policies.Diagnostic{
+		{File: file, Line: 1, Column: 10, Severity: policies.SeverityError, Message: `unsupported version "2", expected 1`},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("diagnostics (-want +got): %s", diff)
+	}
+}
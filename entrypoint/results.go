@@ -0,0 +1,143 @@
+// Copyright 2022 Security Scorecard Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package entrypoint
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/ossf/scorecard-action/options"
+	docs "github.com/ossf/scorecard/v4/docs/checks"
+	sclog "github.com/ossf/scorecard/v4/log"
+	scopts "github.com/ossf/scorecard/v4/options"
+	"github.com/ossf/scorecard/v4/pkg"
+	spol "github.com/ossf/scorecard/v4/policy"
+)
+
+var errUnknownFormat = errors.New("unknown results format")
+
+// resultsRenderer renders the results of a single Scorecard run in several formats.
+type resultsRenderer struct {
+	result    *pkg.ScorecardResult
+	checkDocs docs.Doc
+	pol       *spol.ScorecardPolicy
+	scOpts    *scopts.Options
+	// baseline and deltas compare the results with a previous run, when a baseline file is given.
+	baseline *baselineResults
+	deltas   []checkDelta
+}
+
+// render renders the results in the given format.
+func (r *resultsRenderer) render(w io.Writer, format string) error {
+	logLevel := sclog.ParseLevel(r.scOpts.LogLevel)
+	var err error
+	switch format {
+	case scopts.FormatDefault:
+		err = asString(w, r.result, r.scOpts.ShowDetails, logLevel, r.checkDocs)
+	case scopts.FormatSarif:
+		if r.baseline == nil {
+			err = r.result.AsSARIF(r.scOpts.ShowDetails, logLevel, w, r.checkDocs, r.pol)
+			break
+		}
+		buf := &bytes.Buffer{}
+		if err = r.result.AsSARIF(r.scOpts.ShowDetails, logLevel, buf, r.checkDocs, r.pol); err == nil {
+			err = setSARIFBaselineState(buf, w, r.deltas)
+		}
+	case scopts.FormatJSON:
+		err = r.result.AsJSON2(r.scOpts.ShowDetails, logLevel, r.checkDocs, w)
+	case scopts.FormatRaw:
+		err = r.result.AsRawJSON(w)
+	case options.FormatMarkdown:
+		var markdown string
+		markdown, err = r.markdown()
+		if err == nil {
+			_, err = io.WriteString(w, markdown)
+		}
+	default:
+		return fmt.Errorf("%w: %s", errUnknownFormat, format)
+	}
+	if err != nil {
+		return fmt.Errorf("rendering %s results: %w", format, err)
+	}
+	return nil
+}
+
+// markdown renders the results, and their comparison with the baseline if any, for the job step summary.
+func (r *resultsRenderer) markdown() (string, error) {
+	markdown, err := scorecardResultsAsMarkdown(r.result, r.checkDocs)
+	if err != nil {
+		return "", err
+	}
+	if r.baseline != nil {
+		markdown += deltasAsMarkdown(r.baseline, r.deltas)
+	}
+	return markdown, nil
+}
+
+// writeAdditionalResults renders the results to each of the additional results files in the workspace.
+func (r *resultsRenderer) writeAdditionalResults(workspace string, outputs []options.ResultsOutput) error {
+	for _, o := range outputs {
+		path := filepath.Join(workspace, o.File)
+		f, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("creating results file (%s): %w", path, err)
+		}
+		err = r.render(f, o.Format)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// asString renders the results as text. ScorecardResult.AsString writes the table to os.Stdout whatever
+// the writer, so os.Stdout is pointed at a file for the time of the call, which must not run concurrently.
+// TODO(scorecard): Remove once AsString writes to the given writer.
+func asString(w io.Writer, result *pkg.ScorecardResult, showDetails bool, logLevel sclog.Level,
+	checkDocs docs.Doc,
+) error {
+	out, ok := w.(*os.File)
+	if !ok {
+		tmp, err := os.CreateTemp("", "scorecard-results-*.txt")
+		if err != nil {
+			return fmt.Errorf("creating temp file: %w", err)
+		}
+		defer os.Remove(tmp.Name())
+		defer tmp.Close()
+		out = tmp
+	}
+	stdout := os.Stdout
+	os.Stdout = out
+	err := result.AsString(showDetails, logLevel, checkDocs, out)
+	os.Stdout = stdout
+	if err != nil {
+		return fmt.Errorf("rendering text results: %w", err)
+	}
+	if out == w {
+		return nil
+	}
+	if _, err := out.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("reading text results: %w", err)
+	}
+	if _, err := io.Copy(w, out); err != nil {
+		return fmt.Errorf("writing text results: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+// Copyright 2022 Security Scorecard Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package entrypoint
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ossf/scorecard-action/options"
+	"github.com/ossf/scorecard/v4/checker"
+	docs "github.com/ossf/scorecard/v4/docs/checks"
+	scopts "github.com/ossf/scorecard/v4/options"
+	"github.com/ossf/scorecard/v4/pkg"
+	spol "github.com/ossf/scorecard/v4/policy"
+)
+
+func TestWriteAdditionalResults(t *testing.T) {
+	t.Parallel()
+	checkDocs, err := docs.Read()
+	if err != nil {
+		t.Fatalf("docs.Read: %v", err)
+	}
+	result := &pkg.ScorecardResult{
+		Repo: pkg.RepoInfo{Name: "github.com/owner/repo", CommitSHA: "abc123"},
+		Checks: []checker.CheckResult{
+			{Name: "Binary-Artifacts", Score: 5, Reason: "binaries found in the repo"},
+		},
+	}
+	workspace := t.TempDir()
+	opts := &options.Options{
+		ScorecardOpts:   scopts.New(),
+		GithubWorkspace: workspace,
+		AdditionalResults: []options.ResultsOutput{
+			{Format: scopts.FormatJSON, File: "results.json"},
+			{Format: scopts.FormatSarif, File: "results.sarif"},
+			{Format: options.FormatMarkdown, File: "results.md"},
+			{Format: scopts.FormatDefault, File: "results.txt"},
+		},
+	}
+	pol := &spol.ScorecardPolicy{
+		Version: 1,
+		Policies: map[string]*spol.CheckPolicy{
+			"Binary-Artifacts": {Score: 10, Mode: spol.CheckPolicy_ENFORCED},
+		},
+	}
+	r := &resultsRenderer{result: result, checkDocs: checkDocs, pol: pol, scOpts: opts.ScorecardOpts}
+	if err := r.writeAdditionalResults(opts.GithubWorkspace, opts.AdditionalResults); err != nil {
+		t.Fatalf("writeAdditionalResults: %v", err)
+	}
+	for file, want := range map[string]string{
+		"results.json":  `"name":"github.com/owner/repo"`,
+		"results.sarif": `"ruleId"`,
+		"results.md":    "**Aggregate score: 5.0 / 10**",
+		"results.txt":   "Aggregate score: 5.0 / 10",
+	} {
+		content, err := os.ReadFile(filepath.Join(workspace, file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(content), want) {
+			t.Errorf("%s = %q, want it to contain %q", file, content, want)
+		}
+	}
+
+	err = r.render(&bytes.Buffer{}, "yaml")
+	if !errors.Is(err, errUnknownFormat) {
+		t.Errorf("render(yaml) error = %v, want %v", err, errUnknownFormat)
+	}
+}
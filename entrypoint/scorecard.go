@@ -0,0 +1,122 @@
+// Copyright OpenSSF Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package entrypoint
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ossf/scorecard-action/policies"
+	"github.com/ossf/scorecard/v4/checker"
+	"github.com/ossf/scorecard/v4/clients"
+	sclog "github.com/ossf/scorecard/v4/log"
+	scopts "github.com/ossf/scorecard/v4/options"
+	"github.com/ossf/scorecard/v4/pkg"
+	"github.com/ossf/scorecard/v4/policy"
+)
+
+// runScorecard runs the Scorecard checks the same way as the Scorecard root command, but returns the results
+// instead of only printing them, so they can also be summarized.
+// TODO(scorecard): Use the upstream implementation once it returns the results.
+func runScorecard(ctx context.Context, o *scopts.Options) (*pkg.ScorecardResult, *policy.ScorecardPolicy, error) {
+	// The package manager flags are hidden from the action, so only --repo and --local are handled here.
+	logger := sclog.NewLogger(sclog.ParseLevel(o.LogLevel))
+	repoURI, repoClient, ossFuzzRepoClient, ciiClient, vulnsClient, err := checker.GetClients(
+		ctx, o.Repo, o.Local, logger)
+	if err != nil {
+		return nil, nil, fmt.Errorf("creating clients: %w", err)
+	}
+	defer repoClient.Close()
+	if ossFuzzRepoClient != nil {
+		defer ossFuzzRepoClient.Close()
+	}
+	return runScorecardWithClients(ctx, o, &scorecardClients{
+		repo:       repoURI,
+		repoClient: repoClient,
+		ossFuzz:    ossFuzzRepoClient,
+		cii:        ciiClient,
+		vulns:      vulnsClient,
+	})
+}
+
+// scorecardClients are the clients the Scorecard checks run with.
+type scorecardClients struct {
+	repo       clients.Repo
+	repoClient clients.RepoClient
+	ossFuzz    clients.RepoClient
+	cii        clients.CIIBestPracticesClient
+	vulns      clients.VulnerabilitiesClient
+}
+
+// runScorecardWithClients runs the Scorecard checks with the given clients, which can be shared
+// between several runs, e.g. the OSS-Fuzz client.
+func runScorecardWithClients(ctx context.Context, o *scopts.Options, c *scorecardClients,
+) (*pkg.ScorecardResult, *policy.ScorecardPolicy, error) {
+	// The policy is read by the action, which adds the logging mode to Scorecard's.
+	p, err := policies.ReadFile(o.PolicyFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading policy: %w", err)
+	}
+	pol := p.ToScorecard()
+
+	var requiredRequestTypes []checker.RequestType
+	if o.Local != "" {
+		requiredRequestTypes = append(requiredRequestTypes, checker.FileBased)
+	}
+	if !strings.EqualFold(o.Commit, clients.HeadSHA) {
+		requiredRequestTypes = append(requiredRequestTypes, checker.CommitBased)
+	}
+	enabledChecks, err := policy.GetEnabled(pol, o.ChecksToRun, requiredRequestTypes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("getting enabled checks: %w", err)
+	}
+
+	if o.Format == scopts.FormatDefault {
+		for checkName := range enabledChecks {
+			fmt.Fprintf(os.Stderr, "Starting [%s]\n", checkName)
+		}
+	}
+
+	repoResult, err := pkg.RunScorecards(
+		ctx,
+		c.repo,
+		o.Commit,
+		enabledChecks,
+		c.repoClient,
+		c.ossFuzz,
+		c.cii,
+		c.vulns,
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("running scorecard: %w", err)
+	}
+	repoResult.Metadata = append(repoResult.Metadata, o.Metadata...)
+
+	// Sort them by name
+	sort.Slice(repoResult.Checks, func(i, j int) bool {
+		return repoResult.Checks[i].Name < repoResult.Checks[j].Name
+	})
+
+	if o.Format == scopts.FormatDefault {
+		for checkName := range enabledChecks {
+			fmt.Fprintf(os.Stderr, "Finished [%s]\n", checkName)
+		}
+		fmt.Println("\nRESULTS\n-------")
+	}
+	return &repoResult, pol, nil
+}
@@ -0,0 +1,73 @@
+// Copyright OpenSSF Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package entrypoint
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ossf/scorecard/v4/checker"
+	docs "github.com/ossf/scorecard/v4/docs/checks"
+	"github.com/ossf/scorecard/v4/pkg"
+)
+
+// scorecardResultsAsMarkdown renders the Scorecard results for the job step summary.
+func scorecardResultsAsMarkdown(result *pkg.ScorecardResult, checkDocs docs.Doc) (string, error) {
+	score, err := result.GetAggregateScore(checkDocs)
+	if err != nil {
+		return "", fmt.Errorf("getting aggregate score: %w", err)
+	}
+	out := fmt.Sprintf("## [Scorecard](https://github.com/ossf/scorecard) results for `%s`\n\n", result.Repo.Name)
+	out += fmt.Sprintf("Commit: `%s`\n\n", result.Repo.CommitSHA)
+	out += fmt.Sprintf("**Aggregate score: %s / %d**\n\n", scoreString(score), checker.MaxResultScore)
+	out += "| Check | Score | Reason |\n"
+	out += "| --- | --- | --- |\n"
+	for _, c := range result.Checks {
+		name := c.Name
+		if checkDoc, err := checkDocs.GetCheck(c.Name); err == nil {
+			name = fmt.Sprintf("[%s](%s)", c.Name, checkDoc.GetDocumentationURL(result.Scorecard.CommitSHA))
+		}
+		out += fmt.Sprintf(
+			"| %s | %s | %s |\n", name, scoreString(float64(c.Score)), markdownTableCell(c.Reason),
+		)
+	}
+	return out + "\n", nil
+}
+
+// appendToStepSummary appends markdown to the job step summary file.
+func appendToStepSummary(path, markdown string) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("opening step summary file (%s): %w", path, err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(markdown); err != nil {
+		return fmt.Errorf("writing step summary file (%s): %w", path, err)
+	}
+	return nil
+}
+
+func scoreString(score float64) string {
+	if score == checker.InconclusiveResultScore {
+		return "?"
+	}
+	return fmt.Sprintf("%.1f", score)
+}
+
+// markdownTableCell keeps text on a single line and escapes the pipes, so it fits in a table cell.
+func markdownTableCell(s string) string {
+	return strings.ReplaceAll(strings.Join(strings.Fields(s), " "), "|", "\\|")
+}
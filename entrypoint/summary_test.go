@@ -0,0 +1,70 @@
+// Copyright OpenSSF Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package entrypoint
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ossf/scorecard/v4/checker"
+	docs "github.com/ossf/scorecard/v4/docs/checks"
+	"github.com/ossf/scorecard/v4/pkg"
+)
+
+func TestScorecardResultsAsMarkdown(t *testing.T) {
+	t.Parallel()
+	checkDocs, err := docs.Read()
+	if err != nil {
+		t.Fatalf("docs.Read: %v", err)
+	}
+	result := &pkg.ScorecardResult{
+		Repo: pkg.RepoInfo{Name: "github.com/owner/repo", CommitSHA: "abc123"},
+		Checks: []checker.CheckResult{
+			{Name: "Binary-Artifacts", Score: 10, Reason: "no binaries found in the repo"},
+			{Name: "Fuzzing", Score: checker.InconclusiveResultScore, Reason: "internal error:\nfoo | bar"},
+		},
+	}
+	got, err := scorecardResultsAsMarkdown(result, checkDocs)
+	if err != nil {
+		t.Fatalf("scorecardResultsAsMarkdown: %v", err)
+	}
+	for _, want := range []string{
+		"`github.com/owner/repo`",
+		"**Aggregate score: 10.0 / 10**",
+		"[Binary-Artifacts](",
+		"| 10.0 | no binaries found in the repo |",
+		"| ? | internal error: foo \\| bar |",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("scorecardResultsAsMarkdown() = %q, want it to contain %q", got, want)
+		}
+	}
+
+	summary := filepath.Join(t.TempDir(), "summary.md")
+	for i := 0; i < 2; i++ {
+		if err := appendToStepSummary(summary, "line\n"); err != nil {
+			t.Fatalf("appendToStepSummary: %v", err)
+		}
+	}
+	content, err := os.ReadFile(summary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "line\nline\n" {
+		t.Errorf("step summary = %q, want both writes appended", content)
+	}
+}
@@ -0,0 +1,170 @@
+// Copyright OpenSSF Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// Names of the events triggering the workflows the action supports.
+// https://docs.github.com/en/actions/using-workflows/events-that-trigger-workflows
+const (
+	EventPush                 = "push"
+	EventPullRequest          = "pull_request"
+	EventPullRequestTarget    = "pull_request_target"
+	EventSchedule             = "schedule"
+	EventWorkflowDispatch     = "workflow_dispatch"
+	EventBranchProtectionRule = "branch_protection_rule"
+	EventRelease              = "release"
+)
+
+// Event is the payload of the event which triggered the workflow, as written to GITHUB_EVENT_PATH.
+// Only the fields used by the action are decoded, and the fields which are not part of the payload
+// of the event are left empty.
+// https://docs.github.com/en/webhooks-and-events/webhooks/webhook-events-and-payloads
+type Event struct {
+	// Name is the name of the event, from GITHUB_EVENT_NAME, since the payload doesn't have it.
+	Name string `json:"-"`
+	// Action is the activity type, e.g. `opened` for pull_request or `published` for release.
+	Action     string     `json:"action"`
+	Repository Repository `json:"repository"`
+	Sender     User       `json:"sender"`
+
+	// Ref, Before and After are the ref and the commits before and after a push.
+	Ref    string `json:"ref"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+
+	// PullRequest is set for pull_request and pull_request_target.
+	PullRequest *PullRequest `json:"pull_request"`
+	// Release is set for release.
+	Release *Release `json:"release"`
+	// Schedule is the cron expression which triggered a schedule event.
+	Schedule string `json:"schedule"`
+	// Inputs are the inputs of a workflow_dispatch event, which are strings, booleans or numbers.
+	Inputs map[string]interface{} `json:"inputs"`
+}
+
+// Repository is the repository an event happened in.
+type Repository struct {
+	/*
+		https://docs.github.com/en/actions/reference/workflow-commands-for-github-actions#github_repository_is_fork
+
+		GITHUB_REPOSITORY_IS_FORK is true if the repository is a fork.
+	*/
+	DefaultBranch *string `json:"default_branch"`
+	Fork          *bool   `json:"fork"`
+	Private       *bool   `json:"private"`
+	FullName      string  `json:"full_name"`
+}
+
+// User is a GitHub user or app, e.g. the sender of an event.
+type User struct {
+	Login string `json:"login"`
+	Type  string `json:"type"`
+}
+
+// PullRequest is the pull request of a pull_request or pull_request_target event.
+type PullRequest struct {
+	Number int    `json:"number"`
+	Head   Branch `json:"head"`
+	Base   Branch `json:"base"`
+}
+
+// Branch is the head or base branch of a pull request, at the commit the event happened.
+type Branch struct {
+	Ref string `json:"ref"`
+	SHA string `json:"sha"`
+}
+
+// Release is the release of a release event.
+type Release struct {
+	TagName         string `json:"tag_name"`
+	TargetCommitish string `json:"target_commitish"`
+}
+
+// ReadEvent reads the payload of the named event from the event file.
+func ReadEvent(name, path string) (*Event, error) {
+	log.Printf("getting event from file: %s", path)
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading GitHub event path: %w", err)
+	}
+
+	prettyPrintJSON(content)
+	event := &Event{Name: name}
+	if err := json.Unmarshal(content, event); err != nil {
+		return nil, fmt.Errorf("unmarshalling GitHub event: %w", err)
+	}
+	return event, nil
+}
+
+// IsPullRequest reports whether the event is pull_request or pull_request_target.
+func (e *Event) IsPullRequest() bool {
+	return strings.HasPrefix(e.Name, EventPullRequest) && e.PullRequest != nil
+}
+
+// PullRequestNumber returns the number of the pull request, or 0 if the event is not about one.
+func (e *Event) PullRequestNumber() int {
+	if !e.IsPullRequest() {
+		return 0
+	}
+	return e.PullRequest.Number
+}
+
+// HeadRef returns the head branch of a pull request, or the pushed ref.
+func (e *Event) HeadRef() string {
+	if e.IsPullRequest() {
+		return e.PullRequest.Head.Ref
+	}
+	return e.Ref
+}
+
+// BaseRef returns the base branch of a pull request, or "" for other events.
+func (e *Event) BaseRef() string {
+	if e.IsPullRequest() {
+		return e.PullRequest.Base.Ref
+	}
+	return ""
+}
+
+// HeadSHA returns the head commit of a pull request, or the pushed commit.
+func (e *Event) HeadSHA() string {
+	if e.IsPullRequest() {
+		return e.PullRequest.Head.SHA
+	}
+	return e.After
+}
+
+// BaseSHA returns the base commit of a pull request, or the commit before a push.
+func (e *Event) BaseSHA() string {
+	if e.IsPullRequest() {
+		return e.PullRequest.Base.SHA
+	}
+	return e.Before
+}
+
+// Input returns an input of a workflow_dispatch event, formatted as a string, and whether it is set.
+func (e *Event) Input(name string) (string, bool) {
+	v, ok := e.Inputs[name]
+	if !ok || v == nil {
+		return "", false
+	}
+	return fmt.Sprint(v), true
+}
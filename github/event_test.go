@@ -0,0 +1,180 @@
+// Copyright OpenSSF Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package github
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadEvent(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name        string
+		event       string
+		payload     string
+		wantPR      int
+		wantHeadRef string
+		wantBaseRef string
+		wantHeadSHA string
+		wantBaseSHA string
+		wantSender  string
+	}{
+		{
+			name:  "Push",
+			event: EventPush,
+			payload: `{"ref": "refs/heads/main", "before": "aaa", "after": "bbb",
+				"repository": {"default_branch": "main", "fork": false, "private": false},
+				"sender": {"login": "octocat", "type": "User"}}`,
+			wantHeadRef: "refs/heads/main",
+			wantHeadSHA: "bbb",
+			wantBaseSHA: "aaa",
+			wantSender:  "octocat",
+		},
+		{
+			name:  "PullRequest",
+			event: EventPullRequest,
+			payload: `{"action": "opened", "number": 42, "pull_request": {"number": 42,
+				"head": {"ref": "feature", "sha": "bbb"}, "base": {"ref": "main", "sha": "aaa"}},
+				"sender": {"login": "octocat", "type": "User"}}`,
+			wantPR:      42,
+			wantHeadRef: "feature",
+			wantBaseRef: "main",
+			wantHeadSHA: "bbb",
+			wantBaseSHA: "aaa",
+			wantSender:  "octocat",
+		},
+		{
+			name:  "PullRequestTarget",
+			event: EventPullRequestTarget,
+			payload: `{"action": "synchronize", "pull_request": {"number": 7,
+				"head": {"ref": "fix", "sha": "ddd"}, "base": {"ref": "main", "sha": "ccc"}},
+				"sender": {"login": "dependabot[bot]", "type": "Bot"}}`,
+			wantPR:      7,
+			wantHeadRef: "fix",
+			wantBaseRef: "main",
+			wantHeadSHA: "ddd",
+			wantBaseSHA: "ccc",
+			wantSender:  "dependabot[bot]",
+		},
+		{
+			name:    "Schedule",
+			event:   EventSchedule,
+			payload: `{"schedule": "30 1 * * 6"}`,
+		},
+		{
+			name:        "WorkflowDispatch",
+			event:       EventWorkflowDispatch,
+			payload:     `{"ref": "refs/heads/main", "inputs": {"repository": "org/repo"}, "sender": {"login": "octocat"}}`,
+			wantHeadRef: "refs/heads/main",
+			wantSender:  "octocat",
+		},
+		{
+			name:       "BranchProtectionRule",
+			event:      EventBranchProtectionRule,
+			payload:    `{"action": "edited", "sender": {"login": "octocat"}}`,
+			wantSender: "octocat",
+		},
+		{
+			name:       "Release",
+			event:      EventRelease,
+			payload:    `{"action": "published", "release": {"tag_name": "v1.0.0"}, "sender": {"login": "octocat"}}`,
+			wantSender: "octocat",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			path := filepath.Join(t.TempDir(), "event.json")
+			if err := os.WriteFile(path, []byte(tt.payload), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			e, err := ReadEvent(tt.event, path)
+			if err != nil {
+				t.Fatalf("ReadEvent: %v", err)
+			}
+			if e.Name != tt.event {
+				t.Errorf("Name = %q, want %q", e.Name, tt.event)
+			}
+			if got := e.PullRequestNumber(); got != tt.wantPR {
+				t.Errorf("PullRequestNumber() = %d, want %d", got, tt.wantPR)
+			}
+			if got := e.HeadRef(); got != tt.wantHeadRef {
+				t.Errorf("HeadRef() = %q, want %q", got, tt.wantHeadRef)
+			}
+			if got := e.BaseRef(); got != tt.wantBaseRef {
+				t.Errorf("BaseRef() = %q, want %q", got, tt.wantBaseRef)
+			}
+			if got := e.HeadSHA(); got != tt.wantHeadSHA {
+				t.Errorf("HeadSHA() = %q, want %q", got, tt.wantHeadSHA)
+			}
+			if got := e.BaseSHA(); got != tt.wantBaseSHA {
+				t.Errorf("BaseSHA() = %q, want %q", got, tt.wantBaseSHA)
+			}
+			if e.Sender.Login != tt.wantSender {
+				t.Errorf("Sender.Login = %q, want %q", e.Sender.Login, tt.wantSender)
+			}
+		})
+	}
+}
+
+func TestReadEventPayloads(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "event.json")
+	payload := `{"inputs": {"ref": "v1", "dry_run": true, "count": 3},
+		"release": {"tag_name": "v1.0.0", "target_commitish": "main"}, "schedule": "0 0 * * *",
+		"repository": {"default_branch": "main", "fork": true, "full_name": "org/repo"}}`
+	if err := os.WriteFile(path, []byte(payload), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	e, err := ReadEvent(EventWorkflowDispatch, path)
+	if err != nil {
+		t.Fatalf("ReadEvent: %v", err)
+	}
+	for name, want := range map[string]string{"ref": "v1", "dry_run": "true", "count": "3"} {
+		if got, ok := e.Input(name); !ok || got != want {
+			t.Errorf("Input(%s) = %q, %v, want %q", name, got, ok, want)
+		}
+	}
+	if _, ok := e.Input("missing"); ok {
+		t.Errorf("Input(missing) is set")
+	}
+	if e.Release == nil || e.Release.TagName != "v1.0.0" || e.Release.TargetCommitish != "main" {
+		t.Errorf("Release = %+v", e.Release)
+	}
+	if e.Schedule != "0 0 * * *" {
+		t.Errorf("Schedule = %q", e.Schedule)
+	}
+	if e.Repository.FullName != "org/repo" || e.Repository.Fork == nil || !*e.Repository.Fork {
+		t.Errorf("Repository = %+v", e.Repository)
+	}
+}
+
+func TestReadEventErrors(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	if _, err := ReadEvent(EventPush, filepath.Join(dir, "missing.json")); err == nil {
+		t.Errorf("ReadEvent(missing file) succeeded")
+	}
+	path := filepath.Join(dir, "event.json")
+	if err := os.WriteFile(path, []byte(`{"pull_request": []}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadEvent(EventPullRequest, path); err == nil {
+		t.Errorf("ReadEvent(invalid payload) succeeded")
+	}
+}
@@ -23,33 +23,21 @@ import (
 	"log"
 	"net/http"
 	"net/url"
-	"os"
-
-	"github.com/ossf/scorecard/v4/clients/githubrepo/roundtripper"
-	sclog "github.com/ossf/scorecard/v4/log"
+	"time"
 )
 
 // RepoInfo is a struct for repository information.
 type RepoInfo struct {
-	Repo      repo `json:"repository"`
+	Repo      Repository `json:"repository"`
 	respBytes []byte
 }
 
-type repo struct {
-	/*
-		https://docs.github.com/en/actions/reference/workflow-commands-for-github-actions#github_repository_is_fork
-
-		GITHUB_REPOSITORY_IS_FORK is true if the repository is a fork.
-	*/
-	DefaultBranch *string `json:"default_branch"`
-	Fork          *bool   `json:"fork"`
-	Private       *bool   `json:"private"`
-}
-
 // Client holds a context and roundtripper for querying repo info from GitHub.
 type Client struct {
 	ctx context.Context
 	rt  http.RoundTripper
+	// retryBackoff is the first wait after a rate limit which doesn't tell how long to wait.
+	retryBackoff time.Duration
 }
 
 // SetContext sets a context for a GitHub client.
@@ -62,11 +50,10 @@ func (c *Client) SetTransport(rt http.RoundTripper) {
 	c.rt = rt
 }
 
-// SetDefaultTransport sets the scorecard roundtripper for a GitHub client.
+// SetDefaultTransport sets a roundtripper authenticated with the credentials of Scorecard for a GitHub client.
+// Scorecard's own roundtripper isn't used, as it waits for the primary rate limit to reset for up to an hour.
 func (c *Client) SetDefaultTransport() {
-	logger := sclog.NewLogger(sclog.DefaultLevel)
-	rt := roundtripper.NewTransport(c.ctx, logger)
-	c.rt = rt
+	c.rt = newAuthTransport()
 }
 
 // ParseFromURL is a function to get the repository information.
@@ -95,14 +82,11 @@ func (c *Client) ParseFromURL(baseRepoURL, repoName string) (RepoInfo, error) {
 		return ret, fmt.Errorf("error creating request: %w", err)
 	}
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
-		return ret, fmt.Errorf("error creating request: %w", err)
+		return ret, fmt.Errorf("error getting repo info: %w", err)
 	}
 	defer resp.Body.Close()
-	if err != nil {
-		return ret, fmt.Errorf("error reading response body: %w", err)
-	}
 
 	respBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
@@ -117,23 +101,40 @@ func (c *Client) ParseFromURL(baseRepoURL, repoName string) (RepoInfo, error) {
 	return ret, nil
 }
 
-// ParseFromFile is a function to get the repository information
-// from GitHub event file.
-func (c *Client) ParseFromFile(filepath string) (RepoInfo, error) {
-	var ret RepoInfo
+// ReadRepositoryFile reads a file of a repository at the ref with the contents API, or at its default branch
+// if no ref is given, e.g. the configuration shared in the .github repository of an organization.
+func (c *Client) ReadRepositoryFile(baseRepoURL, repoName, filePath, ref string) ([]byte, error) {
+	baseURL, err := url.Parse(baseRepoURL)
+	if err != nil {
+		return nil, fmt.Errorf("parsing base repo URL: %w", err)
+	}
 
-	log.Printf("getting repo info from file: %s", filepath)
-	repoInfo, err := os.ReadFile(filepath)
+	fileURL, err := baseURL.Parse(fmt.Sprintf("repos/%s/contents/%s", repoName, filePath))
 	if err != nil {
-		return ret, fmt.Errorf("reading GitHub event path: %w", err)
+		return nil, fmt.Errorf("parsing contents endpoint: %w", err)
+	}
+	if ref != "" {
+		fileURL.RawQuery = url.Values{"ref": {ref}}.Encode()
 	}
 
-	prettyPrintJSON(repoInfo)
-	if err := json.Unmarshal(repoInfo, &ret); err != nil {
-		return ret, fmt.Errorf("unmarshalling repo info: %w", err)
+	log.Printf("getting file from URL: %s", fileURL.String())
+	req, err := http.NewRequestWithContext(c.ctx, http.MethodGet, fileURL.String(), nil /*body*/)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
 	}
+	req.Header.Set("Accept", "application/vnd.github.raw")
 
-	return ret, nil
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error getting %s in %s: %w", filePath, repoName, err)
+	}
+	defer resp.Body.Close()
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading response body: %w", err)
+	}
+	return content, nil
 }
 
 // NewClient returns a new Client for querying repo info from GitHub.
@@ -0,0 +1,236 @@
+// Copyright OpenSSF Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// staticToken is a tokens.TokenAccessor of a single token.
+type staticToken string
+
+func (t staticToken) Next() (uint64, string) { return 0, string(t) }
+func (staticToken) Release(uint64)           {}
+
+func newTestClient() *Client {
+	rt := &tokenTransport{inner: http.DefaultTransport, tokens: staticToken("test-token")}
+	return &Client{ctx: context.Background(), rt: rt, retryBackoff: time.Millisecond}
+}
+
+// TestNewClientPrimaryRateLimit checks that the default transport doesn't wait for the primary rate limit
+// to reset, which Scorecard's transport does for up to an hour.
+func TestNewClientPrimaryRateLimit(t *testing.T) {
+	t.Setenv("GITHUB_AUTH_TOKEN", "test-token")
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if got := r.Header.Get("Authorization"); got != "Bearer test-token" {
+			t.Errorf("Authorization = %q, want the token", got)
+		}
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	_, err := NewClient(ctx).ParseFromURL(server.URL+"/", "owner/repo")
+	if !errors.Is(err, ErrRateLimited) {
+		t.Errorf("ParseFromURL() error = %v, want %v", err, ErrRateLimited)
+	}
+	if got := atomic.LoadInt32(&requests); got != 1 {
+		t.Errorf("requests = %d, want 1", got)
+	}
+}
+
+func TestParseFromURL(t *testing.T) {
+	t.Parallel()
+	const repoInfo = `{"default_branch": "main", "fork": false, "private": true}`
+	tests := []struct {
+		name string
+		// responses are written in turn, the last one is repeated.
+		responses    []func(w http.ResponseWriter)
+		wantRequests int32
+		wantErr      error
+	}{
+		{
+			name: "Success",
+			responses: []func(w http.ResponseWriter){
+				func(w http.ResponseWriter) { fmt.Fprint(w, repoInfo) },
+			},
+			wantRequests: 1,
+		},
+		{
+			name: "RetryAfter",
+			responses: []func(w http.ResponseWriter){
+				func(w http.ResponseWriter) {
+					w.Header().Set("Retry-After", "0")
+					w.WriteHeader(http.StatusForbidden)
+				},
+				func(w http.ResponseWriter) { fmt.Fprint(w, repoInfo) },
+			},
+			wantRequests: 2,
+		},
+		{
+			name: "SecondaryRateLimit",
+			responses: []func(w http.ResponseWriter){
+				func(w http.ResponseWriter) {
+					http.Error(w, `{"message": "You have exceeded a secondary rate limit."}`, http.StatusForbidden)
+				},
+				func(w http.ResponseWriter) { w.WriteHeader(http.StatusTooManyRequests) },
+				func(w http.ResponseWriter) { fmt.Fprint(w, repoInfo) },
+			},
+			wantRequests: 3,
+		},
+		{
+			name: "PrimaryRateLimit",
+			responses: []func(w http.ResponseWriter){
+				func(w http.ResponseWriter) {
+					w.Header().Set("X-RateLimit-Remaining", "0")
+					w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Unix(), 10))
+					w.WriteHeader(http.StatusForbidden)
+				},
+				func(w http.ResponseWriter) { fmt.Fprint(w, repoInfo) },
+			},
+			wantRequests: 2,
+		},
+		{
+			name: "StillRateLimited",
+			responses: []func(w http.ResponseWriter){
+				func(w http.ResponseWriter) { w.WriteHeader(http.StatusTooManyRequests) },
+			},
+			wantRequests: maxRetries + 1,
+			wantErr:      ErrRateLimited,
+		},
+		{
+			name: "RetryAfterTooLong",
+			responses: []func(w http.ResponseWriter){
+				func(w http.ResponseWriter) {
+					w.Header().Set("Retry-After", "3600")
+					w.WriteHeader(http.StatusTooManyRequests)
+				},
+			},
+			wantRequests: 1,
+			wantErr:      ErrRateLimited,
+		},
+		{
+			name: "NotFound",
+			responses: []func(w http.ResponseWriter){
+				func(w http.ResponseWriter) { w.WriteHeader(http.StatusNotFound) },
+			},
+			wantRequests: 1,
+			wantErr:      ErrNotFound,
+		},
+		{
+			name: "Unauthorized",
+			responses: []func(w http.ResponseWriter){
+				func(w http.ResponseWriter) { w.WriteHeader(http.StatusUnauthorized) },
+			},
+			wantRequests: 1,
+			wantErr:      ErrUnauthorized,
+		},
+		{
+			name: "Forbidden",
+			responses: []func(w http.ResponseWriter){
+				func(w http.ResponseWriter) {
+					http.Error(w, `{"message": "Resource not accessible by integration"}`, http.StatusForbidden)
+				},
+			},
+			wantRequests: 1,
+			wantErr:      ErrUnauthorized,
+		},
+		{
+			name: "ServerError",
+			responses: []func(w http.ResponseWriter){
+				func(w http.ResponseWriter) { w.WriteHeader(http.StatusInternalServerError) },
+			},
+			wantRequests: 1,
+			wantErr:      errUnexpectedStatus,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var requests int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Authorization") != "Bearer test-token" {
+					t.Errorf("request not sent with the transport of the client")
+				}
+				i := int(atomic.AddInt32(&requests, 1)) - 1
+				if i >= len(tt.responses) {
+					i = len(tt.responses) - 1
+				}
+				tt.responses[i](w)
+			}))
+			defer server.Close()
+
+			got, err := newTestClient().ParseFromURL(server.URL+"/", "org/repo")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseFromURL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if n := atomic.LoadInt32(&requests); n != tt.wantRequests {
+				t.Errorf("requests = %d, want %d", n, tt.wantRequests)
+			}
+			if err == nil && (got.Repo.DefaultBranch == nil || *got.Repo.DefaultBranch != "main") {
+				t.Errorf("ParseFromURL() = %+v", got.Repo)
+			}
+		})
+	}
+}
+
+func TestReadRepositoryFile(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/org/.github/contents/policy.yml" {
+			http.NotFound(w, r)
+			return
+		}
+		if accept := r.Header.Get("Accept"); accept != "application/vnd.github.raw" {
+			t.Errorf("Accept = %q", accept)
+		}
+		fmt.Fprintf(w, "version: 1\n%s", r.URL.Query().Get("ref"))
+	}))
+	defer server.Close()
+
+	c := newTestClient()
+	got, err := c.ReadRepositoryFile(server.URL+"/", "org/.github", "policy.yml", "")
+	if err != nil {
+		t.Fatalf("ReadRepositoryFile: %v", err)
+	}
+	if string(got) != "version: 1\n" {
+		t.Errorf("ReadRepositoryFile() = %q", got)
+	}
+	got, err = c.ReadRepositoryFile(server.URL+"/", "org/.github", "policy.yml", "abc123")
+	if err != nil {
+		t.Fatalf("ReadRepositoryFile: %v", err)
+	}
+	if string(got) != "version: 1\nabc123" {
+		t.Errorf("ReadRepositoryFile(ref) = %q", got)
+	}
+	_, err = c.ReadRepositoryFile(server.URL+"/", "org/.github", "missing.yml", "")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("ReadRepositoryFile(missing.yml) error = %v, want %v", err, ErrNotFound)
+	}
+}
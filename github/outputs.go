@@ -0,0 +1,55 @@
+// Copyright OpenSSF Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package github
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// outputDelimiter delimits the multi-line output values.
+const outputDelimiter = "SCORECARD_ACTION_OUTPUT_EOF"
+
+// WriteOutputs appends step outputs to the GITHUB_OUTPUT file at path, so that the later steps of the job
+// can use them.
+func WriteOutputs(path string, outputs map[string]string) error {
+	names := make([]string, 0, len(outputs))
+	for name := range outputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		value := outputs[name]
+		if strings.Contains(value, "\n") {
+			fmt.Fprintf(&b, "%s<<%s\n%s\n%s\n", name, outputDelimiter, value, outputDelimiter)
+			continue
+		}
+		fmt.Fprintf(&b, "%s=%s\n", name, value)
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("opening output file (%s): %w", path, err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(b.String()); err != nil {
+		return fmt.Errorf("writing output file (%s): %w", path, err)
+	}
+	return nil
+}
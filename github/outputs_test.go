@@ -0,0 +1,48 @@
+// Copyright OpenSSF Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package github
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteOutputs(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "output")
+	if err := os.WriteFile(path, []byte("previous=1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	err := WriteOutputs(path, map[string]string{
+		"score":   "7.5",
+		"details": "line 1\nline 2",
+		"empty":   "",
+	})
+	if err != nil {
+		t.Fatalf("WriteOutputs: %v", err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "previous=1\n" +
+		"details<<" + outputDelimiter + "\nline 1\nline 2\n" + outputDelimiter + "\n" +
+		"empty=\n" +
+		"score=7.5\n"
+	if string(content) != want {
+		t.Errorf("got %q, want %q", content, want)
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright OpenSSF Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package github
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	maxRetries = 3
+	// defaultRetryBackoff is the first wait after a rate limit which doesn't tell how long to wait,
+	// doubled on each retry. GitHub asks to wait at least a minute after a secondary rate limit.
+	defaultRetryBackoff = time.Minute
+	// maxRetryWait bounds the wait for a rate limit, e.g. the primary rate limit may only reset in an hour.
+	maxRetryWait = 5 * time.Minute
+	// maxErrorBodySize bounds what is read of an error response to tell a secondary rate limit apart.
+	maxErrorBodySize = 4096
+)
+
+// Errors of the GitHub REST API.
+var (
+	// ErrNotFound is returned for a repository or a file which doesn't exist, or which the token can't see.
+	ErrNotFound = errors.New("not found")
+	// ErrUnauthorized is returned when the token is missing, invalid, or lacks a permission.
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrRateLimited is returned when the rate limit is still exceeded after retrying.
+	ErrRateLimited = errors.New("rate limited")
+
+	errUnexpectedStatus = errors.New("unexpected status")
+)
+
+// do sends the request with the transport of the client, retrying while rate limited.
+// Responses which aren't successful are returned as errors.
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	client := &http.Client{Transport: c.rt}
+	backoff := c.retryBackoff
+	if backoff == 0 {
+		backoff = defaultRetryBackoff
+	}
+	for attempt := 0; ; attempt++ {
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("error sending request: %w", err)
+		}
+		wait, limited := rateLimitWait(resp, backoff)
+		if !limited {
+			if err := checkStatus(resp); err != nil {
+				resp.Body.Close()
+				return nil, err
+			}
+			return resp, nil
+		}
+		resp.Body.Close()
+		if attempt == maxRetries || wait > maxRetryWait {
+			return nil, fmt.Errorf("%w: %s, retry after %s", ErrRateLimited, req.URL, wait)
+		}
+		log.Printf("rate limited by the GitHub API, retrying in %s", wait)
+		select {
+		case <-req.Context().Done():
+			return nil, fmt.Errorf("error sending request: %w", req.Context().Err())
+		case <-time.After(wait):
+		}
+		backoff *= 2
+	}
+}
+
+// rateLimitWait returns how long to wait before retrying a rate limited request, and whether it is
+// rate limited. Both the primary and the secondary rate limits are answered with 403 or 429.
+// https://docs.github.com/en/rest/overview/resources-in-the-rest-api#rate-limiting
+func rateLimitWait(resp *http.Response, backoff time.Duration) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+	if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "" {
+		if seconds, err := strconv.Atoi(retryAfter); err == nil {
+			return time.Duration(seconds) * time.Second, true
+		}
+		if at, err := http.ParseTime(retryAfter); err == nil {
+			return nonNegative(time.Until(at)), true
+		}
+	}
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			return nonNegative(time.Until(time.Unix(reset, 0))), true
+		}
+		return backoff, true
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return backoff, true
+	}
+	// A secondary rate limit without Retry-After is only told apart from a permission error by its message.
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	if err == nil && strings.Contains(strings.ToLower(string(body)), "secondary rate limit") {
+		return backoff, true
+	}
+	return 0, false
+}
+
+// checkStatus returns an error for the responses which aren't successful.
+func checkStatus(resp *http.Response) error {
+	switch {
+	case resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices:
+		return nil
+	case resp.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("%w: %s", ErrNotFound, resp.Request.URL)
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return fmt.Errorf(
+			"%w: status %d for %s, check the token and its permissions",
+			ErrUnauthorized, resp.StatusCode, resp.Request.URL,
+		)
+	default:
+		return fmt.Errorf("%w: status %d for %s", errUnexpectedStatus, resp.StatusCode, resp.Request.URL)
+	}
+}
+
+func nonNegative(d time.Duration) time.Duration {
+	if d < 0 {
+		return 0
+	}
+	return d
+}
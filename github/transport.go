@@ -0,0 +1,87 @@
+// Copyright OpenSSF Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package github
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/bradleyfalzon/ghinstallation/v2"
+	"github.com/ossf/scorecard/v4/clients/githubrepo/roundtripper/tokens"
+)
+
+// Environment variables of a GitHub App, read the same way as Scorecard does.
+const (
+	githubAppKeyPath        = "GITHUB_APP_KEY_PATH"
+	githubAppID             = "GITHUB_APP_ID"
+	githubAppInstallationID = "GITHUB_APP_INSTALLATION_ID"
+)
+
+// newAuthTransport returns a transport which authenticates the requests with the tokens, or the GitHub App,
+// Scorecard uses. Unlike Scorecard's transport, it doesn't sleep until the primary rate limit resets,
+// so that the client bounds the wait for a rate limit itself.
+func newAuthTransport() http.RoundTripper {
+	if accessor := tokens.MakeTokenAccessor(); accessor != nil {
+		return &tokenTransport{inner: http.DefaultTransport, tokens: accessor}
+	}
+	if keyPath := os.Getenv(githubAppKeyPath); keyPath != "" {
+		transport, err := newAppTransport(keyPath)
+		if err == nil {
+			return transport
+		}
+		log.Printf("error authenticating as a GitHub App: %v", err)
+	}
+	log.Printf("no GitHub token is set, sending unauthenticated requests")
+	return http.DefaultTransport
+}
+
+func newAppTransport(keyPath string) (http.RoundTripper, error) {
+	appID, err := strconv.ParseInt(os.Getenv(githubAppID), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", githubAppID, err)
+	}
+	installationID, err := strconv.ParseInt(os.Getenv(githubAppInstallationID), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", githubAppInstallationID, err)
+	}
+	transport, err := ghinstallation.NewKeyFromFile(http.DefaultTransport, appID, installationID, keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading the GitHub App key: %w", err)
+	}
+	return transport, nil
+}
+
+// tokenTransport authenticates the requests with a GitHub token.
+type tokenTransport struct {
+	inner  http.RoundTripper
+	tokens tokens.TokenAccessor
+}
+
+func (t *tokenTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	id, token := t.tokens.Next()
+	defer t.tokens.Release(id)
+
+	// A RoundTripper must not modify the request.
+	r = r.Clone(r.Context())
+	r.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	resp, err := t.inner.RoundTrip(r)
+	if err != nil {
+		return nil, fmt.Errorf("error in HTTP: %w", err)
+	}
+	return resp, nil
+}
@@ -17,20 +17,28 @@ package main
 import (
 	"os"
 
+	"github.com/ossf/scorecard-action/entrypoint"
+	"github.com/ossf/scorecard-action/entrypoint/dependencydiff"
+	"github.com/ossf/scorecard-action/github"
 	"github.com/ossf/scorecard-action/options"
 )
 
-const (
-	eventPullRequest = "pull_request"
-)
-
 func main() {
+	// The dependency-diff and the policy commands can also be run locally, outside GitHub Actions.
+	if len(os.Args) > 1 && os.Args[1] == dependencydiff.CommandName {
+		RunDependencyDiffCLI(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == entrypoint.PolicyCommandName {
+		RunPolicyCLI(os.Args[2:])
+		return
+	}
 	event := os.Getenv(options.EnvGithubEventName)
 	switch event {
-	case eventPullRequest:
-		RunScorecardAction()
+	case github.EventPullRequest:
+		ghEvent := RunScorecardAction()
 		// This is an experimental feature.
-		RunDependencyDiff()
+		RunDependencyDiff(ghEvent)
 	default:
 		RunScorecardAction()
 	}
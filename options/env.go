@@ -33,7 +33,10 @@ const (
 	EnvGitHubHeadRef           = "GITHUB_HEAD_REF"
 	EnvGithubRepository        = "GITHUB_REPOSITORY"
 	EnvGithubRef               = "GITHUB_REF"
+	EnvGithubSHA               = "GITHUB_SHA"
 	EnvGithubWorkspace         = "GITHUB_WORKSPACE"
+	EnvGithubStepSummary       = "GITHUB_STEP_SUMMARY"
+	EnvGithubOutput            = "GITHUB_OUTPUT"
 	EnvGithubAuthToken         = "GITHUB_AUTH_TOKEN" //nolint:gosec
 	EnvScorecardFork           = "SCORECARD_IS_FORK"
 	EnvScorecardPrivateRepo    = "SCORECARD_PRIVATE_REPOSITORY"
@@ -44,10 +47,38 @@ const (
 	EnvInputInternalRepoToken  = "INPUT_INTERNAL_DEFAULT_TOKEN" //nolint:gosec
 	EnvInputResultsFile        = "INPUT_RESULTS_FILE"
 	EnvInputResultsFormat      = "INPUT_RESULTS_FORMAT"
+	EnvInputAdditionalResults  = "INPUT_ADDITIONAL_RESULTS"
+	EnvInputAllowedRefs        = "INPUT_ALLOWED_REFS"
+	EnvInputRepository         = "INPUT_REPOSITORY"
+	EnvInputRef                = "INPUT_REF"
+	EnvInputRepositories       = "INPUT_REPOSITORIES"
+	EnvInputOrganization       = "INPUT_ORGANIZATION"
+	EnvInputRepositoryFilter   = "INPUT_REPOSITORY_FILTER"
+	EnvInputIncludeForks       = "INPUT_INCLUDE_FORKS"
+	EnvInputIncludeArchived    = "INPUT_INCLUDE_ARCHIVED"
+	EnvInputBatchConcurrency   = "INPUT_BATCH_CONCURRENCY"
+	EnvInputBatchResultsDir    = "INPUT_BATCH_RESULTS_DIR"
 	EnvInputPublishResults     = "INPUT_PUBLISH_RESULTS"
+	EnvInputPolicyFile         = "INPUT_POLICY_FILE"
+	EnvInputOrgPolicy          = "INPUT_ORG_POLICY"
+	EnvInputEnforcePolicy      = "INPUT_ENFORCE_POLICY"
+	EnvInputBaselineFile       = "INPUT_BASELINE_FILE"
+	EnvInputFailOnRegression   = "INPUT_FAIL_ON_REGRESSION"
 	EnvInputChecks             = "INPUT_CHECKS"
 	EnvInputChangeTypes        = "INPUT_CHANGE_TYPES"
 	EnvInputPullRequestHeadSHA = "INPUT_PULL_REQUEST_HEAD_SHA"
+	EnvInputCommentMode        = "INPUT_COMMENT_MODE"
+	EnvInputMinAggregateScore  = "INPUT_MIN_AGGREGATE_SCORE"
+	EnvInputMinCheckScores     = "INPUT_MIN_CHECK_SCORES"
+
+	EnvInputDependencyDiffResultsFile = "INPUT_DEPENDENCY_DIFF_RESULTS_FILE"
+	EnvInputDependencyDiffSarifFile   = "INPUT_DEPENDENCY_DIFF_SARIF_FILE"
+	EnvInputDependencyPolicyFile      = "INPUT_DEPENDENCY_POLICY_FILE"
+	EnvInputDepsDevBaseURL            = "INPUT_DEPS_DEV_BASE_URL"
+	EnvInputDepsDevCacheDir           = "INPUT_DEPS_DEV_CACHE_DIR"
+	EnvInputDependencyDiffSinks       = "INPUT_DEPENDENCY_DIFF_SINKS"
+	EnvInputDependencyDiffReportFile  = "INPUT_DEPENDENCY_DIFF_REPORT_FILE"
+	EnvInputDependencyDiffWebhookURL  = "INPUT_DEPENDENCY_DIFF_WEBHOOK_URL"
 )
 
 // Errors.
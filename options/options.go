@@ -18,6 +18,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path"
 	"strconv"
 	"strings"
 
@@ -25,6 +26,7 @@ import (
 	"golang.org/x/net/context"
 
 	"github.com/ossf/scorecard-action/github"
+	"github.com/ossf/scorecard-action/policies"
 	"github.com/ossf/scorecard/v4/checks"
 	scopts "github.com/ossf/scorecard/v4/options"
 )
@@ -33,18 +35,32 @@ const (
 	defaultScorecardPolicyFile = "/policy.yml"
 	trueStr                    = "true"
 	formatSarif                = scopts.FormatSarif
-
-	pullRequestEvent      = "pull_request"
-	pushEvent             = "push"
-	branchProtectionEvent = "branch_protection_rule"
+	// PublishResultsFile is the JSON results file rendered for publishing, unless one is already asked for.
+	PublishResultsFile = "results.json"
+	// FormatMarkdown renders the results as the markdown used for the job step summary.
+	FormatMarkdown = "markdown"
+
+	pullRequestEvent      = github.EventPullRequest
+	pushEvent             = github.EventPush
+	branchProtectionEvent = github.EventBranchProtectionRule
+	workflowDispatchEvent = github.EventWorkflowDispatch
+	scheduleEvent         = github.EventSchedule
 )
 
 var (
 	// Errors.
-	errGithubEventPathEmpty       = errors.New("GitHub event path is empty")
-	errResultsPathEmpty           = errors.New("results path is empty")
-	errGitHubRepoInfoUnavailable  = errors.New("GitHub repo info inaccessible")
-	errOnlyDefaultBranchSupported = errors.New("only default branch is supported")
+	errGithubEventPathEmpty      = errors.New("GitHub event path is empty")
+	errResultsPathEmpty          = errors.New("results path is empty")
+	errGitHubRepoInfoUnavailable = errors.New("GitHub repo info inaccessible")
+	errRefNotAllowed             = errors.New("ref is not allowed")
+	errInvalidAllowedRefs        = errors.New("invalid allowed refs")
+	errBatchNotSupported         = errors.New("batch mode is only supported with workflow_dispatch and schedule")
+	errInvalidRepositoryFilter   = errors.New("invalid repository filter")
+	errRepositoryNotSupported    = errors.New("repository input is only supported with workflow_dispatch and schedule")
+	errInvalidAdditionalResults  = errors.New("invalid additional results")
+	errBatchInputNotSupported    = errors.New("input is not supported in batch mode")
+	errBaselineFileEmpty         = errors.New("fail_on_regression needs a baseline_file")
+	errRawResultsNotSupported    = errors.New("raw results are only supported with Scorecard v6")
 )
 
 // Options are options for running scorecard via GitHub Actions.
@@ -65,9 +81,14 @@ type Options struct {
 	GithubEventName  string `env:"GITHUB_EVENT_NAME"`
 	GithubEventPath  string `env:"GITHUB_EVENT_PATH"`
 	GithubRef        string `env:"GITHUB_REF"`
+	GithubSHA        string `env:"GITHUB_SHA"`
 	GithubRepository string `env:"GITHUB_REPOSITORY"`
 	GithubWorkspace  string `env:"GITHUB_WORKSPACE"`
 	GithubAPIURL     string `env:"GITHUB_API_URL"`
+	// GithubStepSummary is the job step summary file.
+	GithubStepSummary string `env:"GITHUB_STEP_SUMMARY"`
+	// GithubOutput is the file to write the step outputs to.
+	GithubOutput string `env:"GITHUB_OUTPUT"`
 
 	DefaultBranch string `env:"SCORECARD_DEFAULT_BRANCH"`
 	// TODO(options): This may be better as a bool
@@ -78,8 +99,56 @@ type Options struct {
 	// Input parameters
 	InputResultsFile   string `env:"INPUT_RESULTS_FILE"`
 	InputResultsFormat string `env:"INPUT_RESULTS_FORMAT"`
+	// InputRepository is another repository to score, as owner/name, with workflow_dispatch and schedule events.
+	InputRepository string `env:"INPUT_REPOSITORY"`
+	// InputRef is the commit of InputRepository to score, which defaults to the HEAD of its default branch.
+	InputRef string `env:"INPUT_REF"`
+	// Batch mode scores the listed repositories and those of the organization, with workflow_dispatch
+	// and schedule events.
+	// InputRepositories lists comma or newline-separated repositories, as owner/name.
+	InputRepositories string `env:"INPUT_REPOSITORIES"`
+	// InputOrganization scores the repositories of the organization, filtered by the fields below.
+	InputOrganization     string `env:"INPUT_ORGANIZATION"`
+	InputRepositoryFilter string `env:"INPUT_REPOSITORY_FILTER"`
+	InputIncludeForks     bool   `env:"INPUT_INCLUDE_FORKS"`
+	InputIncludeArchived  bool   `env:"INPUT_INCLUDE_ARCHIVED"`
+	// InputBatchConcurrency is the number of repositories scored at a time.
+	InputBatchConcurrency int `env:"INPUT_BATCH_CONCURRENCY"`
+	// InputBatchResultsDir is the directory of the results files and their index, relative to the workspace.
+	InputBatchResultsDir string `env:"INPUT_BATCH_RESULTS_DIR"`
+	// InputAllowedRefs lists comma-separated globs of the refs to run on besides the default branch,
+	// e.g. `refs/heads/release-*,refs/tags/v*`.
+	InputAllowedRefs string `env:"INPUT_ALLOWED_REFS"`
+	// InputAdditionalResults lists more results files as comma-separated format=path pairs.
+	InputAdditionalResults string `env:"INPUT_ADDITIONAL_RESULTS"`
+
+	// InputPolicyFile is the policy file, relative to the workspace, which defaults to LocalPolicyFile if it exists.
+	InputPolicyFile string `env:"INPUT_POLICY_FILE"`
+	// InputOrgPolicy merges the policy file on top of the default policy of the organization,
+	// kept in its .github repository.
+	InputOrgPolicy bool `env:"INPUT_ORG_POLICY"`
+	// EnforcePolicy fails the run when enforced checks score below their policy, logging checks only warn.
+	EnforcePolicy bool `env:"INPUT_ENFORCE_POLICY"`
+
+	// BaselineFile is the JSON results of a previous run to compare the results with.
+	BaselineFile string `env:"INPUT_BASELINE_FILE"`
+	// FailOnRegression fails the run when checks regressed since the baseline.
+	FailOnRegression bool `env:"INPUT_FAIL_ON_REGRESSION"`
 
 	PublishResults bool
+	// Event is the payload of the event which triggered the workflow, nil if it couldn't be read.
+	Event *github.Event
+	// Policy is the policy merged from PolicySources, nil if the policy file of the action image is used.
+	Policy        *policies.Policy
+	PolicySources []string
+	// AdditionalResults are rendered from the same Scorecard run as the results file.
+	AdditionalResults []ResultsOutput
+}
+
+// ResultsOutput is a results file and the format to render the results in.
+type ResultsOutput struct {
+	Format string
+	File   string
 }
 
 // New creates a new options set for running scorecard via GitHub Actions.
@@ -99,7 +168,13 @@ func New() (*Options, error) {
 		return opts, fmt.Errorf("parsing repo info: %w", err)
 	}
 	opts.setScorecardOpts()
+	if err := opts.setPolicy(); err != nil {
+		return opts, fmt.Errorf("setting policy: %w", err)
+	}
 	opts.setPublishResults()
+	if err := opts.setAdditionalResults(); err != nil {
+		return opts, err
+	}
 	return opts, nil
 }
 
@@ -119,12 +194,35 @@ func (o *Options) Validate() error {
 		return errEmptyGitHubAuthToken
 	}
 
-	if !o.isPullRequestEvent() &&
-		!o.isDefaultBranch() {
-		fmt.Printf("%s not supported with %s event.\n", o.GithubRef, o.GithubEventName)
-		fmt.Printf("Only the default branch %s is supported.\n", o.DefaultBranch)
+	if o.InputRepository != "" && !o.isScheduledOrDispatched() {
+		return fmt.Errorf("%w: %s event", errRepositoryNotSupported, o.GithubEventName)
+	}
+	if o.IsBatch() && !o.isScheduledOrDispatched() {
+		return fmt.Errorf("%w: %s event", errBatchNotSupported, o.GithubEventName)
+	}
+	if inputs := o.batchUnsupportedInputs(); o.IsBatch() && len(inputs) > 0 {
+		return fmt.Errorf("%w: %s", errBatchInputNotSupported, strings.Join(inputs, ", "))
+	}
+	// Without a baseline, nothing can regress and the run would never fail.
+	if o.FailOnRegression && o.BaselineFile == "" {
+		return errBaselineFileEmpty
+	}
+	if _, err := path.Match(o.InputRepositoryFilter, ""); err != nil {
+		return fmt.Errorf("%w: %q: %v", errInvalidRepositoryFilter, o.InputRepositoryFilter, err)
+	}
+	allowed, err := o.isAllowedRef()
+	if err != nil {
+		return err
+	}
+	// The ref of the workflow tells nothing of another repository being scored.
+	if !o.isPullRequestEvent() && !o.isOtherRepository() && !o.IsBatch() && !allowed {
+		fmt.Printf("%s not supported with %s event.\n", o.ref(), o.GithubEventName)
+		fmt.Printf(
+			"Only the default branch %s and the refs matching the allowed_refs input are supported.\n",
+			o.DefaultBranch,
+		)
 
-		return errOnlyDefaultBranchSupported
+		return fmt.Errorf("%w: %s", errRefNotAllowed, o.ref())
 	}
 	if err := o.ScorecardOpts.Validate(); err != nil {
 		return fmt.Errorf("validating scorecard options: %w", err)
@@ -140,13 +238,34 @@ func (o *Options) Validate() error {
 func (o *Options) Print() {
 	fmt.Printf("Event file: %s\n", o.GithubEventPath)
 	fmt.Printf("Event name: %s\n", o.GithubEventName)
-	fmt.Printf("Ref: %s\n", o.ScorecardOpts.Commit)
+	fmt.Printf("Ref: %s\n", o.ScoredRef())
+	fmt.Printf("Commit: %s\n", o.ScorecardOpts.Commit)
+	if o.InputAllowedRefs != "" {
+		fmt.Printf("Allowed refs: %s\n", o.InputAllowedRefs)
+	}
 	fmt.Printf("Repository: %s\n", o.ScorecardOpts.Repo)
 	fmt.Printf("Fork repository: %s\n", o.IsForkStr)
 	fmt.Printf("Private repository: %s\n", o.PrivateRepoStr)
 	fmt.Printf("Publication enabled: %+v\n", o.PublishResults)
 	fmt.Printf("Format: %s\n", o.ScorecardOpts.Format)
+	for _, r := range o.AdditionalResults {
+		fmt.Printf("Additional results: %s (%s)\n", r.File, r.Format)
+	}
 	fmt.Printf("Policy file: %s\n", o.ScorecardOpts.PolicyFile)
+	if len(o.PolicySources) > 0 {
+		fmt.Printf("Policy merged from: %s\n", strings.Join(o.PolicySources, ", "))
+	}
+	fmt.Printf("Policy enforced: %+v\n", o.EnforcePolicy)
+	o.printPolicy()
+	if o.IsBatch() {
+		fmt.Printf("Batch repositories: %s\n", strings.Join(o.BatchRepositories(), ", "))
+		fmt.Printf("Batch organization: %s\n", o.InputOrganization)
+		fmt.Printf("Batch concurrency: %d\n", o.InputBatchConcurrency)
+	}
+	if o.BaselineFile != "" {
+		fmt.Printf("Baseline file: %s\n", o.BaselineFile)
+		fmt.Printf("Fail on regression: %+v\n", o.FailOnRegression)
+	}
 	fmt.Printf("Default branch: %s\n", o.DefaultBranch)
 }
 
@@ -166,7 +285,7 @@ func (o *Options) setScorecardOpts() {
 	// https://github.com/ossf/scorecard/pull/1898.
 	// TODO(options): Consider moving this to its own function.
 	if !o.isPullRequestEvent() {
-		o.ScorecardOpts.Repo = o.GithubRepository
+		o.ScorecardOpts.Repo = o.scoredRepository()
 	} else {
 		o.ScorecardOpts.Local = "."
 	}
@@ -179,7 +298,7 @@ func (o *Options) setScorecardOpts() {
 	if o.InputResultsFormat != "" {
 		o.ScorecardOpts.Format = o.InputResultsFormat
 	}
-	if o.ScorecardOpts.Format == formatSarif && o.ScorecardOpts.PolicyFile == "" {
+	if (o.ScorecardOpts.Format == formatSarif || o.EnforcePolicy) && o.ScorecardOpts.PolicyFile == "" {
 		// TODO(policy): Should we default or error here?
 		o.ScorecardOpts.PolicyFile = defaultScorecardPolicyFile
 	}
@@ -193,8 +312,22 @@ func (o *Options) setScorecardOpts() {
 	o.ScorecardOpts.ShowDetails = true
 
 	// --commit=
-	// TODO(scorecard): Reset commit options. Fix this in scorecard.
+	// The default branch is scored at its HEAD, which runs every check. Other refs, e.g. release branches
+	// and tags, are scored at the commit which triggered the run, which needs Scorecard v6 features.
 	o.ScorecardOpts.Commit = scopts.DefaultCommit
+	switch {
+	case o.isOtherRepository():
+		if o.InputRef != "" {
+			o.ScorecardOpts.Commit = o.InputRef
+			o.ScorecardOpts.EnableScorecardV6 = true
+		}
+	case !o.isPullRequestEvent() && !o.isDefaultBranch() && o.GithubSHA != "":
+		o.ScorecardOpts.Commit = o.GithubSHA
+		o.ScorecardOpts.EnableScorecardV6 = true
+	}
+	if ref := o.ScoredRef(); ref != "" {
+		o.ScorecardOpts.Metadata = append(o.ScorecardOpts.Metadata, "ref:"+ref)
+	}
 
 	// --out-file=
 	if o.ScorecardOpts.ResultsFile == "" {
@@ -202,6 +335,56 @@ func (o *Options) setScorecardOpts() {
 	}
 }
 
+// setAdditionalResults parses the additional results files, e.g. `json=results.json,markdown=results.md`.
+func (o *Options) setAdditionalResults() error {
+	o.AdditionalResults = nil
+	for _, pair := range strings.Split(o.InputAdditionalResults, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		format, file, found := strings.Cut(pair, "=")
+		format, file = strings.TrimSpace(format), strings.TrimSpace(file)
+		if !found || file == "" {
+			return fmt.Errorf("%w: %q, expected format=path", errInvalidAdditionalResults, pair)
+		}
+		switch format {
+		case scopts.FormatDefault, scopts.FormatJSON, FormatMarkdown:
+		case scopts.FormatRaw:
+			// Like Scorecard's format, the raw results are a v6 feature.
+			if _, v6 := os.LookupEnv(scopts.EnvVarScorecardV6); !v6 && !o.ScorecardOpts.EnableScorecardV6 {
+				return fmt.Errorf("%w: %q", errRawResultsNotSupported, pair)
+			}
+		case formatSarif:
+			if o.ScorecardOpts.PolicyFile == "" {
+				o.ScorecardOpts.PolicyFile = defaultScorecardPolicyFile
+			}
+		default:
+			return fmt.Errorf("%w: unknown format %q", errInvalidAdditionalResults, format)
+		}
+		o.AdditionalResults = append(o.AdditionalResults, ResultsOutput{Format: format, File: file})
+	}
+	// Published results are signed as JSON, so render them alongside the results file if not asked already.
+	if o.PublishResults && !o.rendersJSONTo(PublishResultsFile) {
+		o.AdditionalResults = append(
+			o.AdditionalResults, ResultsOutput{Format: scopts.FormatJSON, File: PublishResultsFile},
+		)
+	}
+	return nil
+}
+
+func (o *Options) rendersJSONTo(file string) bool {
+	if o.ScorecardOpts != nil && o.ScorecardOpts.Format == scopts.FormatJSON && o.ScorecardOpts.ResultsFile == file {
+		return true
+	}
+	for _, r := range o.AdditionalResults {
+		if r.Format == scopts.FormatJSON && r.File == file {
+			return true
+		}
+	}
+	return false
+}
+
 // setPublishResults sets whether results should be published based on a
 // repository's visibility.
 func (o *Options) setPublishResults() {
@@ -218,11 +401,12 @@ func (o *Options) setPublishResults() {
 		return
 	}
 
-	o.PublishResults = inputVal && !privateRepo
+	// Results are published for the repository running the workflow only.
+	o.PublishResults = inputVal && !privateRepo && !o.isOtherRepository() && !o.IsBatch()
 }
 
-// setRepoInfo gets the path to the GitHub event and sets the
-// SCORECARD_IS_FORK environment variable.
+// setRepoInfo reads the GitHub event and sets the repository information,
+// e.g. SCORECARD_IS_FORK, from it or else from the REST API.
 // TODO(options): Check if this actually needs to be exported.
 // TODO(options): Choose a more accurate name for what this does.
 func (o *Options) setRepoInfo() error {
@@ -231,42 +415,153 @@ func (o *Options) setRepoInfo() error {
 		return errGithubEventPathEmpty
 	}
 
-	ghClient := github.NewClient(context.Background())
-	if repoInfo, err := ghClient.ParseFromFile(eventPath); err == nil &&
-		o.parseFromRepoInfo(repoInfo) {
-		return nil
+	event, err := github.ReadEvent(o.GithubEventName, eventPath)
+	if err == nil {
+		o.Event = event
+		// The event describes the repository running the workflow, so another repository is looked up.
+		if !o.isOtherRepository() && o.parseFromRepoInfo(event.Repository) {
+			return nil
+		}
 	}
 
-	if repoInfo, err := ghClient.ParseFromURL(o.GithubAPIURL, o.GithubRepository); err == nil &&
-		o.parseFromRepoInfo(repoInfo) {
+	ghClient := github.NewClient(context.Background())
+	repoInfo, err := ghClient.ParseFromURL(o.GithubAPIURL, o.scoredRepository())
+	if err != nil {
+		return fmt.Errorf("%w: %v", errGitHubRepoInfoUnavailable, err)
+	}
+	if o.parseFromRepoInfo(repoInfo.Repo) {
 		return nil
 	}
 
 	return errGitHubRepoInfoUnavailable
 }
 
-func (o *Options) parseFromRepoInfo(repoInfo github.RepoInfo) bool {
-	if repoInfo.Repo.DefaultBranch == nil &&
-		repoInfo.Repo.Fork == nil &&
-		repoInfo.Repo.Private == nil {
+func (o *Options) parseFromRepoInfo(repo github.Repository) bool {
+	if repo.DefaultBranch == nil &&
+		repo.Fork == nil &&
+		repo.Private == nil {
 		return false
 	}
-	if repoInfo.Repo.Private != nil {
-		o.PrivateRepoStr = strconv.FormatBool(*repoInfo.Repo.Private)
+	if repo.Private != nil {
+		o.PrivateRepoStr = strconv.FormatBool(*repo.Private)
 	}
-	if repoInfo.Repo.Fork != nil {
-		o.IsForkStr = strconv.FormatBool(*repoInfo.Repo.Fork)
+	if repo.Fork != nil {
+		o.IsForkStr = strconv.FormatBool(*repo.Fork)
 	}
-	if repoInfo.Repo.DefaultBranch != nil {
-		o.DefaultBranch = *repoInfo.Repo.DefaultBranch
+	if repo.DefaultBranch != nil {
+		o.DefaultBranch = *repo.DefaultBranch
 	}
 	return true
 }
 
 func (o *Options) isPullRequestEvent() bool {
+	if o.Event != nil {
+		return o.Event.IsPullRequest()
+	}
 	return strings.HasPrefix(o.GithubEventName, pullRequestEvent)
 }
 
+// ref returns the ref which triggered the run, from the event or else from GITHUB_REF.
+// Only the payloads of some events, e.g. push, have the full ref.
+func (o *Options) ref() string {
+	if o.Event != nil && strings.HasPrefix(o.Event.Ref, "refs/") {
+		return o.Event.Ref
+	}
+	return o.GithubRef
+}
+
+// IsBatch reports whether several repositories are scored, from the repositories or organization inputs.
+func (o *Options) IsBatch() bool {
+	return strings.TrimSpace(o.InputRepositories) != "" || o.InputOrganization != ""
+}
+
+// BatchRepositories returns the repositories listed in the repositories input.
+func (o *Options) BatchRepositories() []string {
+	repos := []string{}
+	for _, r := range strings.FieldsFunc(o.InputRepositories, func(c rune) bool {
+		return c == ',' || c == '\n'
+	}) {
+		if r = strings.TrimSpace(r); r != "" {
+			repos = append(repos, r)
+		}
+	}
+	return repos
+}
+
+// batchUnsupportedInputs returns the inputs which are set but only apply to a single repository,
+// as the results of each repository of a batch go to their own file.
+func (o *Options) batchUnsupportedInputs() []string {
+	inputs := []string{}
+	if o.BaselineFile != "" {
+		inputs = append(inputs, "baseline_file")
+	}
+	if o.FailOnRegression {
+		inputs = append(inputs, "fail_on_regression")
+	}
+	if o.EnforcePolicy {
+		inputs = append(inputs, "enforce_policy")
+	}
+	if o.InputAdditionalResults != "" {
+		inputs = append(inputs, "additional_results")
+	}
+	return inputs
+}
+
+func (o *Options) isScheduledOrDispatched() bool {
+	return o.GithubEventName == workflowDispatchEvent || o.GithubEventName == scheduleEvent
+}
+
+// isOtherRepository reports whether the repository input asks to score another repository than
+// the one running the workflow.
+func (o *Options) isOtherRepository() bool {
+	return o.InputRepository != "" && o.isScheduledOrDispatched() &&
+		!strings.EqualFold(o.InputRepository, o.GithubRepository)
+}
+
+// scoredRepository returns the repository to score, as owner/name.
+func (o *Options) scoredRepository() string {
+	if o.isOtherRepository() {
+		return o.InputRepository
+	}
+	return o.GithubRepository
+}
+
+// ScoredRef returns the ref the repository is scored at: the ref input or the default branch for
+// another repository, or the ref which triggered the run.
+func (o *Options) ScoredRef() string {
+	if !o.isOtherRepository() {
+		return o.ref()
+	}
+	if o.InputRef != "" {
+		return o.InputRef
+	}
+	if o.DefaultBranch != "" {
+		return "refs/heads/" + o.DefaultBranch
+	}
+	return ""
+}
+
 func (o *Options) isDefaultBranch() bool {
-	return o.GithubRef == fmt.Sprintf("refs/heads/%s", o.DefaultBranch)
+	return o.ref() == fmt.Sprintf("refs/heads/%s", o.DefaultBranch)
+}
+
+// isAllowedRef reports whether the ref is the default branch or matches one of the allowed refs globs.
+func (o *Options) isAllowedRef() (bool, error) {
+	if o.isDefaultBranch() {
+		return true, nil
+	}
+	for _, pattern := range strings.Split(o.InputAllowedRefs, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		matched, err := path.Match(pattern, o.ref())
+		if err != nil {
+			return false, fmt.Errorf("%w: %q: %v", errInvalidAllowedRefs, pattern, err)
+		}
+		if matched {
+			return true, nil
+		}
+	}
+	return false, nil
 }
@@ -16,12 +16,20 @@
 package options
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/ossf/scorecard/v4/checks"
 	"github.com/ossf/scorecard/v4/options"
+
+	"github.com/ossf/scorecard-action/github"
 )
 
 const (
@@ -54,11 +62,14 @@ func TestNew(t *testing.T) {
 		name             string
 		githubEventPath  string
 		githubEventName  string
+		event            map[string]interface{} // overrides fields of the payload, e.g. to match the ref.
 		githubRef        string
 		repo             string
 		resultsFile      string
 		resultsFormat    string
 		publishResults   string
+		allowedRefs      string
+		githubSHA        string
 		want             fields
 		unsetResultsPath bool
 		unsetToken       bool
@@ -107,6 +118,7 @@ func TestNew(t *testing.T) {
 			name:            "SuccessPullRequest",
 			githubEventPath: githubEventPathNonFork,
 			githubEventName: pullRequestEvent,
+			event:           map[string]interface{}{"ref": nil, "pull_request": map[string]interface{}{"number": 1}},
 			githubRef:       "refs/heads/pr-branch",
 			repo:            testRepo,
 			resultsFormat:   "json",
@@ -200,6 +212,7 @@ func TestNew(t *testing.T) {
 			name:            "FailureBranchIsntMain",
 			githubEventPath: githubEventPathNonFork,
 			githubEventName: pushEvent,
+			event:           map[string]interface{}{"ref": "refs/heads/other-branch"},
 			githubRef:       "refs/heads/other-branch",
 			repo:            testRepo,
 			resultsFormat:   "sarif",
@@ -216,7 +229,80 @@ func TestNew(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name:            "SuccessAllowedTag",
+			githubEventPath: githubEventPathNonFork,
+			githubEventName: pushEvent,
+			event:           map[string]interface{}{"ref": "refs/tags/v1.2.0"},
+			githubRef:       "refs/tags/v1.2.0",
+			githubSHA:       "3e90620a1b2c",
+			allowedRefs:     "refs/heads/release-*, refs/tags/v*",
+			repo:            testRepo,
+			resultsFormat:   "sarif",
+			resultsFile:     testResultsFile,
+			want: fields{
+				EnableSarif: true,
+				Format:      formatSarif,
+				PolicyFile:  defaultScorecardPolicyFile,
+				ResultsFile: testResultsFile,
+				Commit:      "3e90620a1b2c",
+				LogLevel:    options.DefaultLogLevel,
+				Repo:        testRepo,
+				ShowDetails: true,
+			},
+			wantErr: false,
+		},
+		{
+			name:            "FailureBranchNotAllowed",
+			githubEventPath: githubEventPathNonFork,
+			githubEventName: pushEvent,
+			event:           map[string]interface{}{"ref": "refs/heads/feature"},
+			githubRef:       "refs/heads/feature",
+			githubSHA:       "3e90620a1b2c",
+			allowedRefs:     "refs/heads/release-*",
+			repo:            testRepo,
+			resultsFormat:   "sarif",
+			resultsFile:     testResultsFile,
+			want: fields{
+				EnableSarif: true,
+				Format:      formatSarif,
+				PolicyFile:  defaultScorecardPolicyFile,
+				ResultsFile: testResultsFile,
+				Commit:      "3e90620a1b2c",
+				LogLevel:    options.DefaultLogLevel,
+				Repo:        testRepo,
+				ShowDetails: true,
+			},
+			wantErr: true,
+		},
+		{
+			name:            "FailureInvalidAllowedRefs",
+			githubEventPath: githubEventPathNonFork,
+			githubEventName: pushEvent,
+			event:           map[string]interface{}{"ref": "refs/tags/v1.2.0"},
+			githubRef:       "refs/tags/v1.2.0",
+			allowedRefs:     "refs/tags/[",
+			repo:            testRepo,
+			resultsFormat:   "sarif",
+			resultsFile:     testResultsFile,
+			want: fields{
+				EnableSarif: true,
+				Format:      formatSarif,
+				PolicyFile:  defaultScorecardPolicyFile,
+				ResultsFile: testResultsFile,
+				Commit:      options.DefaultCommit,
+				LogLevel:    options.DefaultLogLevel,
+				Repo:        testRepo,
+				ShowDetails: true,
+			},
+			wantErr: true,
+		},
 	}
+	// The policy of a pull request is read at its base, none is found.
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+	os.Setenv("GITHUB_API_URL", server.URL+"/")
+	defer os.Unsetenv("GITHUB_API_URL")
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			os.Setenv(EnvGithubAuthToken, testToken)
@@ -230,7 +316,11 @@ func TestNew(t *testing.T) {
 				os.Unsetenv(EnvInputRepoToken)
 			}
 
-			os.Setenv(EnvGithubEventPath, tt.githubEventPath)
+			eventPath := tt.githubEventPath
+			if tt.event != nil {
+				eventPath = writeEvent(t, tt.githubEventPath, tt.event)
+			}
+			os.Setenv(EnvGithubEventPath, eventPath)
 			defer os.Unsetenv(EnvGithubEventPath)
 
 			os.Setenv(EnvGithubEventName, tt.githubEventName)
@@ -242,6 +332,12 @@ func TestNew(t *testing.T) {
 			os.Setenv(EnvGithubRepository, tt.repo)
 			defer os.Unsetenv(EnvGithubRepository)
 
+			os.Setenv(EnvGithubSHA, tt.githubSHA)
+			defer os.Unsetenv(EnvGithubSHA)
+
+			os.Setenv(EnvInputAllowedRefs, tt.allowedRefs)
+			defer os.Unsetenv(EnvInputAllowedRefs)
+
 			os.Setenv(EnvInputResultsFormat, tt.resultsFormat)
 			defer os.Unsetenv(EnvInputResultsFormat)
 
@@ -285,6 +381,89 @@ func TestNew(t *testing.T) {
 	}
 }
 
+// writeEvent writes a copy of the event file with fields of its payload overridden, and returns its path.
+func writeEvent(t *testing.T, path string, fields map[string]interface{}) string {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("os.ReadFile: %v", err)
+	}
+	payload := map[string]interface{}{}
+	if err := json.Unmarshal(content, &payload); err != nil {
+		t.Fatalf("json.Unmarshal: %v", err)
+	}
+	for k, v := range fields {
+		payload[k] = v
+	}
+	content, err = json.Marshal(payload)
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+	eventPath := filepath.Join(t.TempDir(), "event.json")
+	if err := os.WriteFile(eventPath, content, 0o600); err != nil {
+		t.Fatalf("os.WriteFile: %v", err)
+	}
+	return eventPath
+}
+
+func TestEventRef(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name            string
+		event           *github.Event
+		githubEventName string
+		githubRef       string
+		wantRef         string
+		wantPullRequest bool
+		wantDefault     bool
+	}{
+		{
+			name:        "PushEvent",
+			event:       &github.Event{Name: pushEvent, Ref: "refs/heads/main"},
+			githubRef:   "refs/heads/other",
+			wantRef:     "refs/heads/main",
+			wantDefault: true,
+		},
+		{
+			name:            "PullRequestEvent",
+			event:           &github.Event{Name: pullRequestEvent, PullRequest: &github.PullRequest{Number: 1}},
+			githubEventName: pullRequestEvent,
+			githubRef:       "refs/pull/1/merge",
+			wantRef:         "refs/pull/1/merge",
+			wantPullRequest: true,
+		},
+		{
+			name:            "NoEvent",
+			githubEventName: pullRequestEvent,
+			githubRef:       "refs/heads/main",
+			wantRef:         "refs/heads/main",
+			wantPullRequest: true,
+			wantDefault:     true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			o := &Options{
+				Event:           tt.event,
+				GithubEventName: tt.githubEventName,
+				GithubRef:       tt.githubRef,
+				DefaultBranch:   "main",
+			}
+			if got := o.ScoredRef(); got != tt.wantRef {
+				t.Errorf("ScoredRef() = %q, want %q", got, tt.wantRef)
+			}
+			if got := o.isPullRequestEvent(); got != tt.wantPullRequest {
+				t.Errorf("isPullRequestEvent() = %v, want %v", got, tt.wantPullRequest)
+			}
+			if got := o.isDefaultBranch(); got != tt.wantDefault {
+				t.Errorf("isDefaultBranch() = %v, want %v", got, tt.wantDefault)
+			}
+		})
+	}
+}
+
 func TestSetRepoInfo(t *testing.T) {
 	type fields struct {
 		ScorecardOpts           *options.Options
@@ -440,3 +619,250 @@ func TestSetPublishResults(t *testing.T) {
 		})
 	}
 }
+
+func TestSetAdditionalResults(t *testing.T) {
+	tests := []struct {
+		name           string
+		input          string
+		publish        bool
+		v6             bool
+		want           []ResultsOutput
+		wantPolicyFile string
+		wantErr        bool
+	}{
+		{
+			name: "NoInput",
+		},
+		{
+			name:  "SeveralFormats",
+			input: "json=results.json, markdown = results.md,default=results.txt",
+			want: []ResultsOutput{
+				{Format: "json", File: "results.json"},
+				{Format: "markdown", File: "results.md"},
+				{Format: "default", File: "results.txt"},
+			},
+		},
+		{
+			name:           "SarifSetsPolicyFile",
+			input:          "sarif=results.sarif",
+			want:           []ResultsOutput{{Format: "sarif", File: "results.sarif"}},
+			wantPolicyFile: defaultScorecardPolicyFile,
+		},
+		{
+			name:    "PublishAddsJSON",
+			publish: true,
+			want:    []ResultsOutput{{Format: "json", File: PublishResultsFile}},
+		},
+		{
+			name:    "PublishReusesJSON",
+			input:   "json=results.json",
+			publish: true,
+			want:    []ResultsOutput{{Format: "json", File: "results.json"}},
+		},
+		{
+			name:    "MissingPath",
+			input:   "json=",
+			wantErr: true,
+		},
+		{
+			name:    "UnknownFormat",
+			input:   "yaml=results.yaml",
+			wantErr: true,
+		},
+		{
+			name:    "RawWithoutV6",
+			input:   "raw=results.raw.json",
+			wantErr: true,
+		},
+		{
+			name:  "RawWithV6",
+			input: "raw=results.raw.json",
+			v6:    true,
+			want:  []ResultsOutput{{Format: "raw", File: "results.raw.json"}},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			opts := &Options{
+				ScorecardOpts:          options.New(),
+				InputAdditionalResults: tt.input,
+				PublishResults:         tt.publish,
+			}
+			opts.ScorecardOpts.EnableScorecardV6 = tt.v6
+			err := opts.setAdditionalResults()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("setAdditionalResults() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !cmp.Equal(tt.want, opts.AdditionalResults) {
+				t.Errorf("setAdditionalResults(): -want, +got:\n%s", cmp.Diff(tt.want, opts.AdditionalResults))
+			}
+			if opts.ScorecardOpts.PolicyFile != tt.wantPolicyFile {
+				t.Errorf("PolicyFile = %q, want %q", opts.ScorecardOpts.PolicyFile, tt.wantPolicyFile)
+			}
+		})
+	}
+}
+
+func TestScoreOtherRepository(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/org/other" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `{"default_branch": "develop", "fork": false, "private": true}`)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		eventName  string
+		repository string
+		ref        string
+		wantRepo   string
+		wantRef    string
+		wantCommit string
+		wantErr    error
+	}{
+		{
+			name:       "Schedule",
+			eventName:  scheduleEvent,
+			repository: "org/other",
+			wantRepo:   "org/other",
+			wantRef:    "refs/heads/develop",
+			wantCommit: options.DefaultCommit,
+		},
+		{
+			name:       "DispatchWithRef",
+			eventName:  workflowDispatchEvent,
+			repository: "org/other",
+			ref:        "3e90620a1b2c",
+			wantRepo:   "org/other",
+			wantRef:    "3e90620a1b2c",
+			wantCommit: "3e90620a1b2c",
+		},
+		{
+			name:       "PushNotSupported",
+			eventName:  pushEvent,
+			repository: "org/other",
+			wantRepo:   testRepo,
+			wantRef:    "refs/heads/main",
+			wantCommit: options.DefaultCommit,
+			wantErr:    errRepositoryNotSupported,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Setenv(EnvGithubAuthToken, testToken)
+			defer os.Unsetenv(EnvGithubAuthToken)
+
+			os.Setenv(EnvInputRepoToken, testToken)
+			defer os.Unsetenv(EnvInputRepoToken)
+
+			o := &Options{
+				GithubEventName:    tt.eventName,
+				GithubEventPath:    githubEventPathNonFork,
+				GithubRef:          "refs/heads/main",
+				GithubRepository:   testRepo,
+				GithubAPIURL:       server.URL + "/",
+				InputRepository:    tt.repository,
+				InputRef:           tt.ref,
+				InputResultsFile:   testResultsFile,
+				InputResultsFormat: "json",
+			}
+			if err := o.setRepoInfo(); err != nil {
+				t.Fatalf("setRepoInfo: %v", err)
+			}
+			o.setScorecardOpts()
+			if o.ScorecardOpts.Repo != tt.wantRepo {
+				t.Errorf("Repo = %q, want %q", o.ScorecardOpts.Repo, tt.wantRepo)
+			}
+			if got := o.ScoredRef(); got != tt.wantRef {
+				t.Errorf("ScoredRef() = %q, want %q", got, tt.wantRef)
+			}
+			if o.ScorecardOpts.Commit != tt.wantCommit {
+				t.Errorf("Commit = %q, want %q", o.ScorecardOpts.Commit, tt.wantCommit)
+			}
+			if err := o.Validate(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateInputs(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(o *Options)
+		wantErr error
+	}{
+		{
+			name:   "Batch",
+			modify: func(o *Options) {},
+		},
+		{
+			name:    "BatchWithBaseline",
+			modify:  func(o *Options) { o.BaselineFile = "baseline.json" },
+			wantErr: errBatchInputNotSupported,
+		},
+		{
+			name:    "BatchWithFailOnRegression",
+			modify:  func(o *Options) { o.FailOnRegression = true },
+			wantErr: errBatchInputNotSupported,
+		},
+		{
+			name:    "BatchWithEnforcePolicy",
+			modify:  func(o *Options) { o.EnforcePolicy = true },
+			wantErr: errBatchInputNotSupported,
+		},
+		{
+			name:    "FailOnRegressionWithoutBaseline",
+			modify:  func(o *Options) { o.InputRepositories = ""; o.FailOnRegression = true },
+			wantErr: errBaselineFileEmpty,
+		},
+		{
+			name: "FailOnRegressionWithBaseline",
+			modify: func(o *Options) {
+				o.InputRepositories = ""
+				o.FailOnRegression = true
+				o.BaselineFile = "baseline.json"
+			},
+		},
+		{
+			name:    "BatchWithAdditionalResults",
+			modify:  func(o *Options) { o.InputAdditionalResults = "json=results.json" },
+			wantErr: errBatchInputNotSupported,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Setenv(EnvGithubAuthToken, testToken)
+			defer os.Unsetenv(EnvGithubAuthToken)
+
+			os.Setenv(EnvInputRepoToken, testToken)
+			defer os.Unsetenv(EnvInputRepoToken)
+
+			o := &Options{
+				GithubEventName:    scheduleEvent,
+				GithubEventPath:    githubEventPathNonFork,
+				GithubRef:          "refs/heads/main",
+				GithubRepository:   testRepo,
+				InputRepositories:  "org/one,org/two",
+				InputResultsFile:   testResultsFile,
+				InputResultsFormat: "json",
+			}
+			if err := o.setRepoInfo(); err != nil {
+				t.Fatalf("setRepoInfo: %v", err)
+			}
+			o.setScorecardOpts()
+			tt.modify(o)
+			if err := o.Validate(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
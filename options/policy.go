@@ -0,0 +1,187 @@
+// Copyright OpenSSF Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package options
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/net/context"
+
+	"github.com/ossf/scorecard-action/github"
+	"github.com/ossf/scorecard-action/policies"
+)
+
+const (
+	// LocalPolicyFile is looked up in the workspace when no policy file is given.
+	LocalPolicyFile = ".github/scorecard-policy.yml"
+	// The default policy of an organization is kept in its .github repository.
+	orgPolicyRepository = ".github"
+	orgPolicyFile       = "scorecard-policy.yml"
+)
+
+// setPolicy resolves the policy of the repository: the policy file of the repository, merged on top of
+// the default policy of its organization if asked. The policy file of the action image is kept if neither
+// exists.
+func (o *Options) setPolicy() error {
+	sources := []string{}
+	var org *policies.Policy
+	if o.InputOrgPolicy {
+		p, source, err := o.readOrgPolicy()
+		if err != nil {
+			return err
+		}
+		if p != nil {
+			org = p
+			sources = append(sources, source)
+		}
+	}
+	local, source, err := o.readLocalPolicy()
+	if err != nil {
+		return err
+	}
+	if local != nil {
+		sources = append(sources, source)
+	}
+	if org == nil && local == nil {
+		return nil
+	}
+
+	p := policies.Merge(org, local)
+	if err := p.Validate(); err != nil {
+		return fmt.Errorf("validating the policy from %s: %w", strings.Join(sources, ", "), err)
+	}
+	content, err := p.Marshal()
+	if err != nil {
+		return err
+	}
+	// Scorecard reads the policy from a file, so the merged policy is written to one.
+	f, err := os.CreateTemp("", "scorecard-policy-*.yml")
+	if err != nil {
+		return fmt.Errorf("creating the policy file: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(content); err != nil {
+		return fmt.Errorf("writing the policy file: %w", err)
+	}
+	o.ScorecardOpts.PolicyFile = f.Name()
+	o.Policy = p
+	o.PolicySources = sources
+	return nil
+}
+
+// readLocalPolicy reads the policy file input, relative to the workspace, or else the local policy file
+// if it exists. A nil policy is returned if there is no policy file.
+func (o *Options) readLocalPolicy() (*policies.Policy, string, error) {
+	file := o.InputPolicyFile
+	optional := file == ""
+	if optional {
+		file = LocalPolicyFile
+	}
+	if !filepath.IsAbs(file) {
+		if o.isPullRequestEvent() {
+			return o.readBasePolicy(file, optional)
+		}
+		file = filepath.Join(o.GithubWorkspace, file)
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		if optional && errors.Is(err, fs.ErrNotExist) {
+			return nil, "", nil
+		}
+		return nil, "", fmt.Errorf("reading policy file: %w", err)
+	}
+	p, err := policies.Decode(content)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", file, err)
+	}
+	return p, file, nil
+}
+
+// readBasePolicy reads the policy file at the base of the pull request. The workspace has the head of the
+// pull request, which could weaken the policy it is checked against.
+func (o *Options) readBasePolicy(file string, optional bool) (*policies.Policy, string, error) {
+	// Without the base commit in the event, the policy of the default branch is read.
+	ref := ""
+	if o.Event != nil {
+		ref = o.Event.BaseSHA()
+	}
+	source := o.GithubRepository + "/" + file
+	if ref != "" {
+		source += "@" + ref
+	}
+
+	ghClient := github.NewClient(context.Background())
+	content, err := ghClient.ReadRepositoryFile(o.GithubAPIURL, o.GithubRepository, file, ref)
+	if err != nil {
+		if optional && errors.Is(err, github.ErrNotFound) {
+			return nil, "", nil
+		}
+		return nil, "", fmt.Errorf("reading policy file: %w", err)
+	}
+	p, err := policies.Decode(content)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", source, err)
+	}
+	return p, source, nil
+}
+
+// readOrgPolicy reads the default policy of the organization owning the scored repository.
+// A nil policy is returned if the organization has none.
+func (o *Options) readOrgPolicy() (*policies.Policy, string, error) {
+	owner, _, _ := strings.Cut(o.scoredRepository(), "/")
+	repo := owner + "/" + orgPolicyRepository
+	source := repo + "/" + orgPolicyFile
+
+	ghClient := github.NewClient(context.Background())
+	content, err := ghClient.ReadRepositoryFile(o.GithubAPIURL, repo, orgPolicyFile, "")
+	if err != nil {
+		if errors.Is(err, github.ErrNotFound) {
+			fmt.Printf("No organization policy found in %s.\n", source)
+			return nil, "", nil
+		}
+		return nil, "", fmt.Errorf("reading the organization policy: %w", err)
+	}
+	p, err := policies.Decode(content)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", source, err)
+	}
+	return p, source, nil
+}
+
+// printPolicy prints the effective policy, or the error keeping it from being read.
+func (o *Options) printPolicy() {
+	p := o.Policy
+	if p == nil {
+		var err error
+		if p, err = policies.ReadFile(o.ScorecardOpts.PolicyFile); err != nil {
+			fmt.Printf("Policy: %v\n", err)
+			return
+		}
+		if p == nil {
+			return
+		}
+	}
+	content, err := p.Marshal()
+	if err != nil {
+		fmt.Printf("Policy: %v\n", err)
+		return
+	}
+	fmt.Printf("Policy:\n%s", content)
+}
@@ -0,0 +1,268 @@
+// Copyright OpenSSF Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//nolint
+package options
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ossf/scorecard/v4/options"
+
+	"github.com/ossf/scorecard-action/github"
+	"github.com/ossf/scorecard-action/policies"
+)
+
+func TestSetPolicy(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/org/.github/contents/scorecard-policy.yml":
+			fmt.Fprint(w, `
+version: 1
+policies:
+  Binary-Artifacts:
+    mode: enforced
+    score: 10
+  Code-Review:
+    mode: enforced
+    score: 8
+`)
+		case "/repos/org/repo/contents/.github/scorecard-policy.yml":
+			if r.URL.Query().Get("ref") != "base-sha" {
+				http.NotFound(w, r)
+				return
+			}
+			fmt.Fprint(w, "version: 1\npolicies:\n  Code-Review:\n    mode: enforced\n    score: 9\n")
+		case "/repos/broken/.github/contents/scorecard-policy.yml":
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	const localPolicy = `
+version: 1
+policies:
+  Code-Review:
+    mode: logging
+    score: 5
+`
+	tests := []struct {
+		name        string
+		repository  string
+		orgPolicy   bool
+		localPolicy string
+		policyFile  string
+		event       *github.Event
+		want        *policies.Policy
+		wantSources []string
+		wantErr     error
+		wantAnyErr  bool
+	}{
+		{
+			name:       "NoPolicy",
+			repository: "org/repo",
+		},
+		{
+			name:        "Local",
+			repository:  "org/repo",
+			localPolicy: localPolicy,
+			want: &policies.Policy{
+				Version: 1,
+				Policies: map[string]policies.CheckPolicy{
+					"Code-Review": {Mode: policies.ModeLogging, Score: 5},
+				},
+			},
+			wantSources: []string{LocalPolicyFile},
+		},
+		{
+			name:       "Org",
+			repository: "org/repo",
+			orgPolicy:  true,
+			want: &policies.Policy{
+				Version: 1,
+				Policies: map[string]policies.CheckPolicy{
+					"Binary-Artifacts": {Mode: policies.ModeEnforced, Score: 10},
+					"Code-Review":      {Mode: policies.ModeEnforced, Score: 8},
+				},
+			},
+			wantSources: []string{"org/.github/scorecard-policy.yml"},
+		},
+		{
+			name:        "LocalOverridesOrg",
+			repository:  "org/repo",
+			orgPolicy:   true,
+			localPolicy: localPolicy,
+			want: &policies.Policy{
+				Version: 1,
+				Policies: map[string]policies.CheckPolicy{
+					"Binary-Artifacts": {Mode: policies.ModeEnforced, Score: 10},
+					"Code-Review":      {Mode: policies.ModeLogging, Score: 5},
+				},
+			},
+			wantSources: []string{"org/.github/scorecard-policy.yml", LocalPolicyFile},
+		},
+		{
+			name:        "PartialLocalPolicy",
+			repository:  "org/repo",
+			orgPolicy:   true,
+			localPolicy: "policies:\n  Code-Review:\n    mode: disabled\n",
+			want: &policies.Policy{
+				Version: 1,
+				Policies: map[string]policies.CheckPolicy{
+					"Binary-Artifacts": {Mode: policies.ModeEnforced, Score: 10},
+					"Code-Review":      {Mode: policies.ModeDisabled},
+				},
+			},
+			wantSources: []string{"org/.github/scorecard-policy.yml", LocalPolicyFile},
+		},
+		{
+			name:        "NoOrgPolicy",
+			repository:  "other/repo",
+			orgPolicy:   true,
+			localPolicy: localPolicy,
+			want: &policies.Policy{
+				Version: 1,
+				Policies: map[string]policies.CheckPolicy{
+					"Code-Review": {Mode: policies.ModeLogging, Score: 5},
+				},
+			},
+			wantSources: []string{LocalPolicyFile},
+		},
+		{
+			name:        "MergedPolicyInvalid",
+			repository:  "org/repo",
+			orgPolicy:   true,
+			localPolicy: "policies:\n  Unknown-Check:\n    mode: enforced\n    score: 5\n",
+			wantErr:     policies.ErrInvalidPolicy,
+		},
+		{
+			name:        "UnknownField",
+			repository:  "org/repo",
+			localPolicy: "version: 1\npolicy: {}\n",
+			wantErr:     policies.ErrInvalidPolicy,
+		},
+		{
+			name:       "OrgPolicyUnavailable",
+			repository: "broken/repo",
+			orgPolicy:  true,
+			wantAnyErr: true,
+		},
+		{
+			// The head of the pull request weakens the policy, which is read at its base.
+			name:        "PullRequestReadsBase",
+			repository:  "org/repo",
+			localPolicy: localPolicy,
+			event: &github.Event{
+				Name:        github.EventPullRequest,
+				PullRequest: &github.PullRequest{Base: github.Branch{SHA: "base-sha"}},
+			},
+			want: &policies.Policy{
+				Version: 1,
+				Policies: map[string]policies.CheckPolicy{
+					"Code-Review": {Mode: policies.ModeEnforced, Score: 9},
+				},
+			},
+			wantSources: []string{"org/repo/" + LocalPolicyFile + "@base-sha"},
+		},
+		{
+			name:        "PullRequestNoBasePolicy",
+			repository:  "org/repo",
+			localPolicy: localPolicy,
+			event: &github.Event{
+				Name:        github.EventPullRequest,
+				PullRequest: &github.PullRequest{Base: github.Branch{SHA: "other-sha"}},
+			},
+		},
+		{
+			name:       "PolicyFileMissing",
+			repository: "org/repo",
+			policyFile: "missing.yml",
+			wantErr:    os.ErrNotExist,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			workspace := t.TempDir()
+			if tt.localPolicy != "" {
+				if err := os.MkdirAll(filepath.Join(workspace, ".github"), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(filepath.Join(workspace, LocalPolicyFile), []byte(tt.localPolicy), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			o := &Options{
+				ScorecardOpts:    options.New(),
+				GithubRepository: tt.repository,
+				GithubWorkspace:  workspace,
+				GithubAPIURL:     server.URL + "/",
+				InputPolicyFile:  tt.policyFile,
+				InputOrgPolicy:   tt.orgPolicy,
+				Event:            tt.event,
+			}
+			o.ScorecardOpts.PolicyFile = defaultScorecardPolicyFile
+			err := o.setPolicy()
+			if tt.wantErr != nil || tt.wantAnyErr {
+				if err == nil || (tt.wantErr != nil && !errors.Is(err, tt.wantErr)) {
+					t.Fatalf("setPolicy() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("setPolicy(): %v", err)
+			}
+			if diff := cmp.Diff(tt.want, o.Policy); diff != "" {
+				t.Errorf("policy (-want +got): %s", diff)
+			}
+			sources := []string{}
+			for _, s := range o.PolicySources {
+				sources = append(sources, strings.TrimPrefix(s, workspace+string(filepath.Separator)))
+			}
+			if len(tt.wantSources) == 0 {
+				tt.wantSources = []string{}
+			}
+			if diff := cmp.Diff(tt.wantSources, sources); diff != "" {
+				t.Errorf("sources (-want +got): %s", diff)
+			}
+			if tt.want == nil {
+				if o.ScorecardOpts.PolicyFile != defaultScorecardPolicyFile {
+					t.Errorf("policy file = %s, want %s", o.ScorecardOpts.PolicyFile, defaultScorecardPolicyFile)
+				}
+				return
+			}
+			// The policy file given to Scorecard is the merged policy.
+			got, err := policies.ReadFile(o.ScorecardOpts.PolicyFile)
+			os.Remove(o.ScorecardOpts.PolicyFile)
+			if err != nil {
+				t.Fatalf("ReadFile: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("policy file (-want +got): %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,232 @@
+// Copyright 2022 Security Scorecard Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policies
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/ossf/scorecard/v4/checker"
+	"github.com/ossf/scorecard/v4/checks"
+)
+
+// Diagnostic severities. Errors make the policy unusable, warnings point at likely mistakes.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+var yamlErrorLine = regexp.MustCompile(`line (\d+)`)
+
+// Diagnostic is a problem found in a policy file, at a 1-based line and column.
+type Diagnostic struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Severity string `json:"severity"`
+	Check    string `json:"check,omitempty"`
+	Message  string `json:"message"`
+}
+
+// String formats the diagnostic like compilers do, as file:line:column: severity: message.
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s: %s", d.File, d.Line, d.Column, d.Severity, d.Message)
+}
+
+// HasErrors reports whether any of the diagnostics is an error.
+func HasErrors(diagnostics []Diagnostic) bool {
+	for _, d := range diagnostics {
+		if d.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// linter collects the diagnostics of a policy file.
+type linter struct {
+	file        string
+	diagnostics []Diagnostic
+}
+
+// checkEntry is a check listed in the policies, kept to find the checks listed twice.
+type checkEntry struct {
+	line int
+	mode string
+}
+
+// Lint checks the policy file against the rules of Validate, reporting every problem with its position
+// rather than the first one. It also reports the checks listed more than once, e.g. with conflicting modes,
+// and the checks which can never fail.
+func Lint(file string, content []byte) []Diagnostic {
+	l := &linter{file: file}
+	doc := &yaml.Node{}
+	if err := yaml.Unmarshal(content, doc); err != nil {
+		line := 1
+		if m := yamlErrorLine.FindStringSubmatch(err.Error()); m != nil {
+			line, _ = strconv.Atoi(m[1])
+		}
+		l.add(&yaml.Node{Line: line, Column: 1}, SeverityError, "", "invalid YAML: %v", err)
+		return l.diagnostics
+	}
+	if len(doc.Content) == 0 {
+		l.add(&yaml.Node{Line: 1, Column: 1}, SeverityError, "", "empty policy")
+		return l.diagnostics
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		l.add(root, SeverityError, "", "policy is not a mapping")
+		return l.diagnostics
+	}
+	hasVersion := false
+	for _, kv := range pairs(root) {
+		key, value := kv[0], kv[1]
+		switch key.Value {
+		case "version":
+			hasVersion = true
+			l.lintVersion(value)
+		case "policies":
+			l.lintPolicies(value)
+		default:
+			l.add(key, SeverityError, "", "unknown field %q", key.Value)
+		}
+	}
+	if !hasVersion {
+		l.add(root, SeverityError, "", "missing version, expected %d", Version)
+	}
+	sort.SliceStable(l.diagnostics, func(i, j int) bool {
+		a, b := l.diagnostics[i], l.diagnostics[j]
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return l.diagnostics
+}
+
+func (l *linter) lintVersion(value *yaml.Node) {
+	var version int
+	if err := value.Decode(&version); err != nil || version != Version {
+		l.add(value, SeverityError, "", "unsupported version %q, expected %d", value.Value, Version)
+	}
+}
+
+func (l *linter) lintPolicies(value *yaml.Node) {
+	if value.Kind != yaml.MappingNode {
+		l.add(value, SeverityError, "", "policies is not a mapping of check names")
+		return
+	}
+	// Check names are compared case-insensitively, since Scorecard matches them so.
+	seen := map[string]checkEntry{}
+	for _, kv := range pairs(value) {
+		key, cp := kv[0], kv[1]
+		name := l.lintCheckName(key)
+		mode := l.lintCheckPolicy(name, cp)
+		if previous, ok := seen[strings.ToLower(name)]; ok {
+			if previous.mode != mode {
+				l.add(key, SeverityError, name, "conflicting modes of %s: %q here and %q at line %d",
+					name, mode, previous.mode, previous.line)
+			} else {
+				l.add(key, SeverityWarning, name, "%s is already listed at line %d", name, previous.line)
+			}
+			continue
+		}
+		seen[strings.ToLower(name)] = checkEntry{line: key.Line, mode: mode}
+	}
+}
+
+// lintCheckName reports unknown check names and returns the name of the check.
+func (l *linter) lintCheckName(key *yaml.Node) string {
+	name := key.Value
+	if _, ok := checks.GetAll()[name]; ok {
+		return name
+	}
+	for known := range checks.GetAll() {
+		if strings.EqualFold(known, name) {
+			l.add(key, SeverityError, name, "unknown check %q, did you mean %q?", name, known)
+			return known
+		}
+	}
+	l.add(key, SeverityError, name, "unknown check %q", name)
+	return name
+}
+
+// lintCheckPolicy reports the problems of the policy of a check and returns its mode.
+func (l *linter) lintCheckPolicy(name string, value *yaml.Node) string {
+	if value.Kind != yaml.MappingNode {
+		l.add(value, SeverityError, name, "policy of %s is not a mapping of mode and score", name)
+		return ""
+	}
+	var (
+		mode                string
+		modeNode, scoreNode *yaml.Node
+	)
+	for _, kv := range pairs(value) {
+		key, v := kv[0], kv[1]
+		switch key.Value {
+		case "mode":
+			modeNode, mode = v, v.Value
+			switch mode {
+			case ModeEnforced, ModeLogging, ModeDisabled:
+			default:
+				l.add(v, SeverityError, name, "unknown mode %q of %s, expected %s, %s or %s",
+					mode, name, ModeEnforced, ModeLogging, ModeDisabled)
+			}
+		case "score":
+			scoreNode = v
+			var score int
+			if err := v.Decode(&score); err != nil {
+				l.add(v, SeverityError, name, "score %q of %s is not a number", v.Value, name)
+			} else if score < 0 || score > checker.MaxResultScore {
+				l.add(v, SeverityError, name, "impossible score %d of %s, scores are from 0 to %d",
+					score, name, checker.MaxResultScore)
+			}
+		default:
+			l.add(key, SeverityError, name, "unknown field %q of %s", key.Value, name)
+		}
+	}
+	switch {
+	case modeNode == nil:
+		l.add(value, SeverityError, name, "missing mode of %s", name)
+	case (mode == ModeEnforced || mode == ModeLogging) && scoreNode == nil:
+		l.add(value, SeverityWarning, name, "missing score of %s, which never fails", name)
+	}
+	return mode
+}
+
+func (l *linter) add(n *yaml.Node, severity, check, format string, args ...interface{}) {
+	l.diagnostics = append(l.diagnostics, Diagnostic{
+		File:     l.file,
+		Line:     n.Line,
+		Column:   n.Column,
+		Severity: severity,
+		Check:    check,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// pairs returns the key and value nodes of a mapping node.
+func pairs(n *yaml.Node) [][2]*yaml.Node {
+	kvs := make([][2]*yaml.Node, 0, len(n.Content)/2)
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		kvs = append(kvs, [2]*yaml.Node{n.Content[i], n.Content[i+1]})
+	}
+	return kvs
+}
@@ -0,0 +1,162 @@
+// Copyright 2022 Security Scorecard Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policies
+
+import (
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLint(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		content string
+		want    []Diagnostic
+	}{
+		{
+			name:    "Valid",
+			content: "version: 1\npolicies:\n  Fuzzing:\n    mode: enforced\n    score: 5\n",
+			want:    nil,
+		},
+		{
+			name:    "Version",
+			content: "version: 2\npolicies: {}\n",
+			want: []Diagnostic{
+				{Line: 1, Column: 10, Severity: SeverityError, Message: `unsupported version "2", expected 1`},
+			},
+		},
+		{
+			name:    "MissingVersion",
+			content: "policies: {}\n",
+			want: []Diagnostic{
+				{Line: 1, Column: 1, Severity: SeverityError, Message: "missing version, expected 1"},
+			},
+		},
+		{
+			name:    "UnknownChecks",
+			content: "version: 1\npolicies:\n" +
+				"  fuzzing:\n    mode: enforced\n    score: 5\n" +
+				"  Fuzz:\n    mode: enforced\n    score: 5\n",
+			want: []Diagnostic{
+				{
+					Line: 3, Column: 3, Severity: SeverityError, Check: "fuzzing",
+					Message: `unknown check "fuzzing", did you mean "Fuzzing"?`,
+				},
+				{Line: 6, Column: 3, Severity: SeverityError, Check: "Fuzz", Message: `unknown check "Fuzz"`},
+			},
+		},
+		{
+			name:    "ImpossibleScores",
+			content: "version: 1\npolicies:\n" +
+				"  Fuzzing:\n    mode: enforced\n    score: 11\n" +
+				"  License:\n    mode: logging\n    score: -1\n",
+			want: []Diagnostic{
+				{
+					Line: 5, Column: 12, Severity: SeverityError, Check: "Fuzzing",
+					Message: "impossible score 11 of Fuzzing, scores are from 0 to 10",
+				},
+				{
+					Line: 8, Column: 12, Severity: SeverityError, Check: "License",
+					Message: "impossible score -1 of License, scores are from 0 to 10",
+				},
+			},
+		},
+		{
+			name:    "ConflictingModes",
+			content: "version: 1\npolicies:\n" +
+				"  Fuzzing:\n    mode: enforced\n    score: 5\n" +
+				"  Fuzzing:\n    mode: disabled\n",
+			want: []Diagnostic{
+				{
+					Line: 6, Column: 3, Severity: SeverityError, Check: "Fuzzing",
+					Message: `conflicting modes of Fuzzing: "disabled" here and "enforced" at line 3`,
+				},
+			},
+		},
+		{
+			name:    "Duplicate",
+			content: "version: 1\npolicies:\n  Fuzzing:\n    mode: disabled\n  Fuzzing:\n    mode: disabled\n",
+			want: []Diagnostic{
+				{
+					Line: 5, Column: 3, Severity: SeverityWarning, Check: "Fuzzing",
+					Message: "Fuzzing is already listed at line 3",
+				},
+			},
+		},
+		{
+			name:    "Modes",
+			content: "version: 1\npolicies:\n" +
+				"  Fuzzing:\n    mode: warn\n    score: 5\n" +
+				"  License:\n    score: 5\n" +
+				"  SAST:\n    mode: enforced\n",
+			want: []Diagnostic{
+				{
+					Line: 4, Column: 11, Severity: SeverityError, Check: "Fuzzing",
+					Message: `unknown mode "warn" of Fuzzing, expected enforced, logging or disabled`,
+				},
+				{Line: 7, Column: 5, Severity: SeverityError, Check: "License", Message: "missing mode of License"},
+				{
+					Line: 9, Column: 5, Severity: SeverityWarning, Check: "SAST",
+					Message: "missing score of SAST, which never fails",
+				},
+			},
+		},
+		{
+			name:    "UnknownFields",
+			content: "version: 1\nrules: {}\npolicies:\n  Fuzzing:\n    mode: enforced\n    scor: 5\n    score: 5\n",
+			want: []Diagnostic{
+				{Line: 2, Column: 1, Severity: SeverityError, Message: `unknown field "rules"`},
+				{Line: 6, Column: 5, Severity: SeverityError, Check: "Fuzzing", Message: `unknown field "scor" of Fuzzing`},
+			},
+		},
+		{
+			name:    "InvalidYAML",
+			content: "version: 1\npolicies: [\n",
+			want: []Diagnostic{
+				{
+					Line: 2, Column: 1, Severity: SeverityError,
+					Message: "invalid YAML: yaml: line 2: did not find expected node content",
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			for i := range tt.want {
+				tt.want[i].File = "policy.yml"
+			}
+			got := Lint("policy.yml", []byte(tt.content))
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Lint() (-want +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestLintTemplate(t *testing.T) {
+	t.Parallel()
+	content, err := os.ReadFile("template.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := Lint("template.yml", content); len(got) > 0 {
+		t.Errorf("Lint(template.yml) = %v, want no diagnostics", got)
+	}
+}
@@ -0,0 +1,223 @@
+// Copyright 2022 Security Scorecard Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package policies reads the Scorecard policy files of the action, like template.yml, and evaluates
+// the Scorecard results against them.
+package policies
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/ossf/scorecard/v4/checker"
+	"github.com/ossf/scorecard/v4/checks"
+	"github.com/ossf/scorecard/v4/pkg"
+	spol "github.com/ossf/scorecard/v4/policy"
+)
+
+// Check policy modes. Scorecard only knows enforced and disabled, logging is added by the action.
+const (
+	ModeEnforced = "enforced"
+	// ModeLogging reports the violations of a check without failing the run.
+	ModeLogging  = "logging"
+	ModeDisabled = "disabled"
+
+	// Version is the supported version of the policy files.
+	Version = 1
+)
+
+// ErrInvalidPolicy is returned for policy files which can't be used.
+var ErrInvalidPolicy = errors.New("invalid policy")
+
+// CheckPolicy is the minimum score of a check and how a lower score is handled.
+type CheckPolicy struct {
+	Mode  string `yaml:"mode"`
+	Score int    `yaml:"score"`
+}
+
+// Policy is a Scorecard policy file.
+type Policy struct {
+	Version  int                    `yaml:"version"`
+	Policies map[string]CheckPolicy `yaml:"policies"`
+}
+
+// Violation is a check scoring below the score of its policy.
+type Violation struct {
+	Check     string
+	Score     int
+	Threshold int
+	Mode      string
+}
+
+// ReadFile reads and validates the policy file. A nil policy is returned if no file is given.
+func ReadFile(path string) (*Policy, error) {
+	if path == "" {
+		return nil, nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading policy file: %w", err)
+	}
+	p, err := Parse(content)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return p, nil
+}
+
+// Parse parses and validates a policy.
+func Parse(content []byte) (*Policy, error) {
+	p, err := Decode(content)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Decode decodes a policy without validating it, e.g. a policy which is merged before being used.
+// Unknown fields are rejected, since they are likely misspelled.
+func Decode(content []byte) (*Policy, error) {
+	p := &Policy{}
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(p); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPolicy, err)
+	}
+	return p, nil
+}
+
+// Merge merges the policy on top of the base policy: its check policies replace those of the base,
+// and its version replaces the base's if set. Either policy can be nil.
+func Merge(base, p *Policy) *Policy {
+	merged := &Policy{Policies: map[string]CheckPolicy{}}
+	for _, src := range []*Policy{base, p} {
+		if src == nil {
+			continue
+		}
+		if src.Version != 0 {
+			merged.Version = src.Version
+		}
+		for name, cp := range src.Policies {
+			merged.Policies[name] = cp
+		}
+	}
+	return merged
+}
+
+// Marshal encodes the policy as YAML.
+func (p *Policy) Marshal() ([]byte, error) {
+	out, err := yaml.Marshal(p)
+	if err != nil {
+		return nil, fmt.Errorf("encoding policy: %w", err)
+	}
+	return out, nil
+}
+
+// Validate checks the policy against the schema of the policy files: the version, the check names,
+// which are keys of the policies, and the mode and the score of each check.
+func (p *Policy) Validate() error {
+	if p.Version != Version {
+		return fmt.Errorf("%w: version %d", ErrInvalidPolicy, p.Version)
+	}
+	for _, name := range p.checks() {
+		cp := p.Policies[name]
+		if _, ok := checks.GetAll()[name]; !ok {
+			return fmt.Errorf("%w: unknown check %s", ErrInvalidPolicy, name)
+		}
+		switch cp.Mode {
+		case ModeEnforced, ModeLogging, ModeDisabled:
+		default:
+			return fmt.Errorf("%w: mode %q of %s", ErrInvalidPolicy, cp.Mode, name)
+		}
+		if cp.Score < 0 || cp.Score > checker.MaxResultScore {
+			return fmt.Errorf("%w: score %d of %s", ErrInvalidPolicy, cp.Score, name)
+		}
+	}
+	return nil
+}
+
+// ToScorecard converts the policy for Scorecard. The logging checks are enforced by Scorecard, so that
+// they are run and their violations are in the SARIF results.
+func (p *Policy) ToScorecard() *spol.ScorecardPolicy {
+	if p == nil {
+		return nil
+	}
+	sp := &spol.ScorecardPolicy{Version: int32(p.Version), Policies: map[string]*spol.CheckPolicy{}}
+	for name, cp := range p.Policies {
+		mode := spol.CheckPolicy_ENFORCED
+		if cp.Mode == ModeDisabled {
+			mode = spol.CheckPolicy_DISABLED
+		}
+		sp.Policies[name] = &spol.CheckPolicy{Score: int32(cp.Score), Mode: mode}
+	}
+	return sp
+}
+
+// Evaluate returns the enforced and logging checks of the results scoring below their policy, sorted
+// by check name. Inconclusive scores are no violations, like in the SARIF results.
+func (p *Policy) Evaluate(result *pkg.ScorecardResult) []Violation {
+	violations := []Violation{}
+	if p == nil {
+		return violations
+	}
+	for _, c := range result.Checks {
+		if v, ok := p.Violation(c.Name, c.Score); ok {
+			violations = append(violations, v)
+		}
+	}
+	sort.Slice(violations, func(i, j int) bool { return violations[i].Check < violations[j].Check })
+	return violations
+}
+
+// Violation returns the violation of the policy by a score of the check, if it's below the score of
+// an enforced or logging policy. Inconclusive scores are no violations.
+func (p *Policy) Violation(check string, score int) (Violation, bool) {
+	if p == nil {
+		return Violation{}, false
+	}
+	cp, ok := p.Policies[check]
+	if !ok || cp.Mode == ModeDisabled || score == checker.InconclusiveResultScore || score >= cp.Score {
+		return Violation{}, false
+	}
+	return Violation{Check: check, Score: score, Threshold: cp.Score, Mode: cp.Mode}, true
+}
+
+// Enforced returns the violations of enforced checks, which fail the run.
+func Enforced(violations []Violation) []Violation {
+	enforced := []Violation{}
+	for _, v := range violations {
+		if v.Mode == ModeEnforced {
+			enforced = append(enforced, v)
+		}
+	}
+	return enforced
+}
+
+func (p *Policy) checks() []string {
+	names := make([]string, 0, len(p.Policies))
+	for name := range p.Policies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,177 @@
+// Copyright 2022 Security Scorecard Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policies
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ossf/scorecard/v4/checker"
+	"github.com/ossf/scorecard/v4/pkg"
+	spol "github.com/ossf/scorecard/v4/policy"
+)
+
+func TestReadFileTemplate(t *testing.T) {
+	t.Parallel()
+	p, err := ReadFile("template.yml")
+	if err != nil {
+		t.Fatalf("ReadFile(template.yml): %v", err)
+	}
+	if got := p.Policies["CII-Best-Practices"]; got != (CheckPolicy{Mode: ModeEnforced, Score: 5}) {
+		t.Errorf("CII-Best-Practices policy = %+v", got)
+	}
+}
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		content string
+		wantErr bool
+	}{
+		{
+			name:    "LoggingMode",
+			content: "version: 1\npolicies:\n  Fuzzing:\n    score: 5\n    mode: logging\n",
+		},
+		{
+			name:    "InvalidVersion",
+			content: "version: 2\n",
+			wantErr: true,
+		},
+		{
+			name:    "UnknownCheck",
+			content: "version: 1\npolicies:\n  Fuzz:\n    score: 5\n    mode: enforced\n",
+			wantErr: true,
+		},
+		{
+			name:    "UnknownMode",
+			content: "version: 1\npolicies:\n  Fuzzing:\n    score: 5\n    mode: warn\n",
+			wantErr: true,
+		},
+		{
+			name:    "ScoreOutOfRange",
+			content: "version: 1\npolicies:\n  Fuzzing:\n    score: 11\n    mode: enforced\n",
+			wantErr: true,
+		},
+		{
+			name:    "UnknownField",
+			content: "version: 1\npolicies:\n  Fuzzing:\n    score: 5\n    mod: enforced\n",
+			wantErr: true,
+		},
+		{
+			name:    "InvalidYAML",
+			content: "version: [1",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := Parse([]byte(tt.content))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidPolicy) {
+				t.Errorf("Parse() error = %v, want %v", err, ErrInvalidPolicy)
+			}
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	t.Parallel()
+	base := &Policy{
+		Version: Version,
+		Policies: map[string]CheckPolicy{
+			"Code-Review": {Mode: ModeEnforced, Score: 8},
+			"Fuzzing":     {Mode: ModeEnforced, Score: 5},
+		},
+	}
+	p := &Policy{
+		Policies: map[string]CheckPolicy{
+			"Fuzzing": {Mode: ModeDisabled},
+			"License": {Mode: ModeLogging, Score: 10},
+		},
+	}
+	want := &Policy{
+		Version: Version,
+		Policies: map[string]CheckPolicy{
+			"Code-Review": {Mode: ModeEnforced, Score: 8},
+			"Fuzzing":     {Mode: ModeDisabled},
+			"License":     {Mode: ModeLogging, Score: 10},
+		},
+	}
+	if diff := cmp.Diff(want, Merge(base, p)); diff != "" {
+		t.Errorf("Merge() (-want +got): %s", diff)
+	}
+	if diff := cmp.Diff(base, Merge(nil, base)); diff != "" {
+		t.Errorf("Merge(nil) (-want +got): %s", diff)
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	t.Parallel()
+	p := &Policy{
+		Version: Version,
+		Policies: map[string]CheckPolicy{
+			"Branch-Protection": {Mode: ModeEnforced, Score: 8},
+			"Code-Review":       {Mode: ModeLogging, Score: 8},
+			"Fuzzing":           {Mode: ModeDisabled, Score: 8},
+			"License":           {Mode: ModeEnforced, Score: 8},
+			"Maintained":        {Mode: ModeEnforced, Score: 8},
+		},
+	}
+	result := &pkg.ScorecardResult{
+		Checks: []checker.CheckResult{
+			{Name: "Maintained", Score: 3},
+			{Name: "Code-Review", Score: 2},
+			{Name: "Fuzzing", Score: 0},
+			{Name: "License", Score: checker.InconclusiveResultScore},
+			{Name: "Branch-Protection", Score: 9},
+			{Name: "SAST", Score: 0},
+		},
+	}
+	want := []Violation{
+		{Check: "Code-Review", Score: 2, Threshold: 8, Mode: ModeLogging},
+		{Check: "Maintained", Score: 3, Threshold: 8, Mode: ModeEnforced},
+	}
+	got := p.Evaluate(result)
+	if !cmp.Equal(want, got) {
+		t.Errorf("Evaluate(): -want, +got:\n%s", cmp.Diff(want, got))
+	}
+	if got := Enforced(got); !cmp.Equal(want[1:], got) {
+		t.Errorf("Enforced(): -want, +got:\n%s", cmp.Diff(want[1:], got))
+	}
+
+	if v, ok := p.Violation("Maintained", 7); !ok || v.Threshold != 8 {
+		t.Errorf("Violation() = %v, %v, want the violation of Maintained", v, ok)
+	}
+	if _, ok := (*Policy)(nil).Violation("Maintained", 0); ok {
+		t.Errorf("Violation() of a nil policy, want none")
+	}
+
+	sp := p.ToScorecard()
+	for name, wantMode := range map[string]spol.CheckPolicy_Mode{
+		"Code-Review": spol.CheckPolicy_ENFORCED,
+		"Fuzzing":     spol.CheckPolicy_DISABLED,
+		"Maintained":  spol.CheckPolicy_ENFORCED,
+	} {
+		if got := sp.GetPolicies()[name].GetMode(); got != wantMode {
+			t.Errorf("ToScorecard() mode of %s = %v, want %v", name, got, wantMode)
+		}
+	}
+}
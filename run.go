@@ -20,22 +20,47 @@ import (
 
 	"github.com/ossf/scorecard-action/entrypoint"
 	"github.com/ossf/scorecard-action/entrypoint/dependencydiff"
+	"github.com/ossf/scorecard-action/github"
+	"github.com/ossf/scorecard-action/options"
 )
 
 // RunDependencyDiff runs the dependency-diff on pull requests.
 // TODO (#issue number): add e2e test.
-func RunDependencyDiff() {
+func RunDependencyDiff(event *github.Event) {
 	// Run the dependency-diff on pull requests.
 	ctx := context.Background()
-	err := dependencydiff.New(ctx)
+	err := dependencydiff.New(ctx, event)
 	if err != nil {
 		log.Fatalf("error running dependency-diff: %v", err)
 	}
 }
 
-func RunScorecardAction() {
-	// Run the root Scorecard-action.
-	action, err := entrypoint.New()
+// RunDependencyDiffCLI runs the dependency-diff locally and prints the report.
+func RunDependencyDiffCLI(args []string) {
+	cmd := dependencydiff.NewCommand()
+	cmd.SetArgs(args)
+	if err := cmd.ExecuteContext(context.Background()); err != nil {
+		log.Fatalf("error running dependency-diff: %v", err)
+	}
+}
+
+// RunPolicyCLI runs the policy command locally, e.g. to validate policy files.
+func RunPolicyCLI(args []string) {
+	cmd := entrypoint.NewPolicyCommand()
+	cmd.SetArgs(args)
+	if err := cmd.Execute(); err != nil {
+		log.Fatalf("error running policy command: %v", err)
+	}
+}
+
+// RunScorecardAction runs the root Scorecard-action and returns the GitHub event it read.
+func RunScorecardAction() *github.Event {
+	// The options are read here, so that the dependency-diff reuses the event they read.
+	opts, err := options.New()
+	if err != nil {
+		log.Fatalf("creating scorecard entrypoint: creating new options: %v", err)
+	}
+	action, err := entrypoint.NewWithOptions(opts)
 	if err != nil {
 		log.Fatalf("creating scorecard entrypoint: %v", err)
 	}
@@ -45,14 +70,15 @@ func RunScorecardAction() {
 	}
 
 	// if os.Getenv(options.EnvInputPublishResults) == "true" {
-	// 	// Get json results by re-running scorecard.
-	// 	jsonPayload, err := signing.GetJSONScorecardResults()
+	// 	// Get the json results rendered by the entrypoint.
+	// 	resultsFile := filepath.Join(os.Getenv(options.EnvGithubWorkspace), options.PublishResultsFile)
+	// 	jsonPayload, err := signing.GetJSONScorecardResults(resultsFile)
 	// 	if err != nil {
-	// 		log.Fatalf("error generating json scorecard results: %v", err)
+	// 		log.Fatalf("error reading json scorecard results: %v", err)
 	// 	}
 
 	// 	// Sign json results.
-	// 	if err = signing.SignScorecardResult("results.json"); err != nil {
+	// 	if err = signing.SignScorecardResult(resultsFile); err != nil {
 	// 		log.Fatalf("error signing scorecard json results: %v", err)
 	// 	}
 
@@ -64,4 +90,5 @@ func RunScorecardAction() {
 	// 		log.Fatalf("error processing signature: %v", err)
 	// 	}
 	// }
+	return opts.Event
 }
@@ -27,8 +27,6 @@ import (
 	"os"
 	"time"
 
-	"github.com/ossf/scorecard-action/entrypoint" //nolint
-	"github.com/ossf/scorecard-action/options"
 	sigOpts "github.com/sigstore/cosign/cmd/cosign/cli/options"
 	"github.com/sigstore/cosign/cmd/cosign/cli/sign"
 )
@@ -60,24 +58,10 @@ func SignScorecardResult(scorecardResultsFile string) error {
 	return nil
 }
 
-// GetJSONScorecardResults changes output settings to json and runs scorecard again.
-// TODO: run scorecard only once and generate multiple formats together.
-func GetJSONScorecardResults() ([]byte, error) {
-	defer os.Setenv(options.EnvInputResultsFile, os.Getenv(options.EnvInputResultsFile))
-	defer os.Setenv(options.EnvInputResultsFormat, os.Getenv(options.EnvInputResultsFormat))
-	os.Setenv(options.EnvInputResultsFile, "results.json")
-	os.Setenv(options.EnvInputResultsFormat, "json")
-
-	actionJSON, err := entrypoint.New()
-	if err != nil {
-		return nil, fmt.Errorf("creating scorecard entrypoint: %w", err)
-	}
-	if err := actionJSON.Execute(); err != nil {
-		return nil, fmt.Errorf("error during command execution: %w", err)
-	}
-
-	// Get json output data from file.
-	jsonPayload, err := os.ReadFile(os.Getenv(options.EnvInputResultsFile))
+// GetJSONScorecardResults reads the JSON results rendered by the entrypoint from the same Scorecard run
+// as the other results files.
+func GetJSONScorecardResults(resultsFile string) ([]byte, error) {
+	jsonPayload, err := os.ReadFile(resultsFile)
 	if err != nil {
 		return nil, fmt.Errorf("reading scorecard json results from file: %w", err)
 	}